@@ -3,78 +3,251 @@ package app
 import (
 	"context"
 	"douyinLiveCollectors/backend/common/collectors"
+	"douyinLiveCollectors/backend/common/config"
+	"douyinLiveCollectors/backend/common/handler"
 	"douyinLiveCollectors/backend/common/log"
+	"douyinLiveCollectors/backend/common/replay"
+	"douyinLiveCollectors/backend/common/room"
+	"douyinLiveCollectors/backend/common/tracker"
+	"errors"
+	"fmt"
 	"github.com/wailsapp/wails/v2/pkg/runtime"
-	"strconv"
+	"os"
+	"sync"
+	"time"
 )
 
 var Logger = log.GetLogger()
 
+// RoomOutput 是推送给前端的单条直播间消息，Result 为渲染后的文本，HTML 为保留富文本样式的同一行，
+// Event 为结构化的原始事件
+type RoomOutput struct {
+	LiveId uint64
+	Method string
+	Result string
+	HTML   string
+	Event  handler.Event
+	Replay bool // 来自回放而不是实时采集
+}
+
+// ReplayResult 在回放结束时通过 "replay-finished" 事件推送给前端
+type ReplayResult struct {
+	Path   string
+	LiveId uint64
+	Frames int
+	Error  string
+}
+
 // App struct
 type App struct {
-	lv        *collectors.LiveViewer
-	connected bool
-	currentID uint64
-	ctx       context.Context
+	rooms   *room.Manager
+	ctx     context.Context
+	mu      sync.Mutex
+	replays map[string]context.CancelFunc
 }
 
 func NewApp() *App {
-	return &App{}
+	a := &App{replays: make(map[string]context.CancelFunc)}
+	a.rooms = room.NewManager(a.emitOutput, a.emitLifecycle)
+	return a
 }
 
 func (a *App) Startup(ctx context.Context) {
 	a.ctx = ctx
+
+	file := os.Getenv("DOUYIN_CONFIG")
+	if file == "" {
+		file = config.DefaultPath
+	}
+	if _, err := config.Load(file); err != nil {
+		Logger.Error("Load config %s failed: %v", file, err)
+	}
 }
 
+// GetConfig 返回当前生效的配置
+func (a *App) GetConfig() config.Config {
+	return config.Get()
+}
+
+// SaveConfig 校验并保存配置，对之后启动的直播间生效
+func (a *App) SaveConfig(cfg config.Config) string {
+	if err := config.Save(cfg); err != nil {
+		return fmt.Sprintf("保存配置失败: %v", err)
+	}
+	return "配置已保存，新连接的直播间生效"
+}
+
+// Shutdown 停止所有直播间的采集与回放
 func (a *App) Shutdown() {
-	if a.lv != nil {
-		a.lv.Stop()
+	a.rooms.StopAll()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, cancel := range a.replays {
+		cancel()
 	}
-	a.lv = nil
-	a.connected = false
-	a.currentID = 0
 }
+
+// Start 兼容单直播间的调用方式，等同于 StartRoom
 func (a *App) Start(id uint64) string {
-	if a.connected && a.currentID == id {
+	return a.StartRoom(id)
+}
+
+func (a *App) StartRoom(id uint64) string {
+	err := a.rooms.Start(id)
+	switch {
+	case err == nil:
+		return "连接成功"
+	case errors.Is(err, room.RoomAlreadyStarted):
 		return "连接已建立，不能重复连接"
+	case errors.Is(err, collectors.TtwIdNotFound):
+		return "连接失败: 未获取到 ttwid"
+	case errors.Is(err, collectors.RoomIdNotFound):
+		return "连接失败: 直播间不存在或未开播"
+	case errors.Is(err, collectors.SignatureFailed):
+		return fmt.Sprintf("连接失败: 签名生成失败 (%v)", err)
+	case errors.Is(err, collectors.DialFailed):
+		return fmt.Sprintf("连接失败: WebSocket 连接失败 (%v)", err)
+	case errors.Is(err, collectors.RequestFailed):
+		return fmt.Sprintf("连接失败: 请求直播间失败 (%v)", err)
+	default:
+		Logger.Info("StartRoom %d failed: %v", id, err)
+		return fmt.Sprintf("连接失败: %v", err)
 	}
+}
 
-	if a.connected && a.currentID != id {
-		a.Shutdown()
+// StopRoom 断开连接，直播间的状态与统计保留到 RemoveRoom
+func (a *App) StopRoom(id uint64) string {
+	if err := a.rooms.Stop(id); err != nil {
+		return "直播间未连接"
 	}
+	return "连接已断开"
+}
 
-	a.lv = collectors.NewLiveViewer(id)
-	a.connected = true
-	a.currentID = id
-
-	Logger.SetLiveId(strconv.FormatUint(a.currentID, 10))
-	//err = os.MkdirAll(logDir, os.ModePerm)
-	//if err != nil {
-	//	return fmt.Sprintf("创建日志目录失败: %v", err)
-	//}
-	//
-	//filename := fmt.Sprintf("%d.log", id)
-	//filePath := filepath.Join(logDir, filename)
-	//
-	//a.logFile, err = os.OpenFile(filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	//if err != nil {
-	//	return "日志文件创建失败"
-	//}
-	a.lv.Start()
+// RemoveRoom 清除直播间及其统计，仍在采集时先断开连接
+func (a *App) RemoveRoom(id uint64) string {
+	if err := a.rooms.Remove(id); err != nil {
+		return "直播间不存在"
+	}
+	return "已清除"
+}
 
-	go func() {
-		for output := range a.lv.Out {
+func (a *App) ListRooms() []room.Status {
+	return a.rooms.List()
+}
+
+func (a *App) RoomStatus(id uint64) (room.Status, error) {
+	return a.rooms.Status(id)
+}
+
+// DriftReport 返回直播间中未定义的字段、未知方法与解码失败率
+func (a *App) DriftReport(id uint64) (handler.DriftReport, error) {
+	return a.rooms.DriftReport(id)
+}
+
+// ProductTimeline 返回直播间每个商品的讲解时间段及期间的聊天、礼物数
+func (a *App) ProductTimeline(id uint64) ([]tracker.ProductWindow, error) {
+	return a.rooms.ProductTimeline(id)
+}
 
-			runtime.EventsEmit(a.ctx, "new-output", output.Result)
+// MatchTimeline 返回体育赛事直播间的当前比分与比分变化历史
+func (a *App) MatchTimeline(id uint64) (tracker.MatchTimeline, error) {
+	return a.rooms.MatchTimeline(id)
+}
+
+// FanTicketSeries 返回直播间本场音浪的变化，可作为主播收入的近似值
+func (a *App) FanTicketSeries(id uint64) ([]tracker.FanTicketPoint, error) {
+	return a.rooms.FanTicketSeries(id)
+}
+
+// NoticeScenes 返回直播间按场景归类的系统消息统计
+func (a *App) NoticeScenes(id uint64) ([]tracker.SceneStat, error) {
+	return a.rooms.NoticeScenes(id)
+}
+
+// ListRecordings 列出配置的 recordDir 中的帧归档
+func (a *App) ListRecordings() ([]replay.Recording, error) {
+	return replay.List(config.Get().RecordDir)
+}
 
-			//if a.logFile != nil {
-			//	_, err := a.logFile.WriteString(output.Result + "\n")
-			//	if err != nil {
-			//		Logger.Infof("写入日志文件失败:", err.Error())
-			//	}
-			//}
+// StartReplay 回放归档文件，speed 为 1 时按实时速度，<= 0 时尽快回放。
+// offset 为从会话开始后第几秒开始回放，之前的帧被跳过
+func (a *App) StartReplay(path string, speed float64, offset float64) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.replays[path]; ok {
+		return "该文件正在回放"
+	}
+	player, err := replay.New(path, replay.Options{
+		Speed:   speed,
+		Offset:  time.Duration(offset * float64(time.Second)),
+		Handler: handler.Options{Catalog: config.Get().Catalog()},
+	})
+	if err != nil {
+		return fmt.Sprintf("回放失败: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	a.replays[path] = cancel
+
+	liveId := player.Header().LiveId
+	go func() {
+		for event := range player.Out {
+			a.emit(liveId, event, true)
 		}
 	}()
+	go func() {
+		err := player.Run(ctx)
+		a.mu.Lock()
+		delete(a.replays, path)
+		a.mu.Unlock()
+		cancel()
 
-	return "连接成功"
+		result := ReplayResult{Path: path, LiveId: liveId, Frames: player.Frames()}
+		if err != nil && !errors.Is(err, context.Canceled) {
+			result.Error = err.Error()
+		}
+		if a.ctx != nil {
+			runtime.EventsEmit(a.ctx, "replay-finished", result)
+		}
+	}()
+	return "开始回放"
+}
+
+func (a *App) StopReplay(path string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	cancel, ok := a.replays[path]
+	if !ok {
+		return "该文件未在回放"
+	}
+	cancel()
+	return "回放已停止"
+}
+
+func (a *App) emitOutput(liveId uint64, event handler.Event) {
+	a.emit(liveId, event, false)
+}
+
+func (a *App) emit(liveId uint64, event handler.Event, fromReplay bool) {
+	if a.ctx == nil {
+		return
+	}
+	text := handler.TextFormatter.Format(event)
+	if text == "" {
+		return
+	}
+	runtime.EventsEmit(a.ctx, "new-output", RoomOutput{
+		LiveId: liveId,
+		Method: event.Meta().Method,
+		Result: text,
+		HTML:   handler.HTMLFormatter.Format(event),
+		Event:  event,
+		Replay: fromReplay,
+	})
+}
+
+func (a *App) emitLifecycle(event collectors.LifecycleEvent) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, "lifecycle", event)
 }
@@ -0,0 +1,92 @@
+package app
+
+import (
+	"douyinLiveCollectors/backend/common/config"
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "app-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestStartRoomAgainstMockServer(t *testing.T) {
+	user := mockserver.NewUser(1, "viewer")
+	s := mockserver.New(mockserver.NewResponse(1, mockserver.Chat(user, "hi")))
+	s.Rooms["1001"] = mockserver.RoomId
+	s.Rooms["1002"] = mockserver.RoomId
+	defer s.Close()
+	config.Set(s.Config())
+
+	a := NewApp()
+	defer a.Shutdown()
+
+	if got := a.StartRoom(1001); got != "连接成功" {
+		t.Fatalf("StartRoom(1001) = %q", got)
+	}
+	if got := a.StartRoom(1001); got != "连接已建立，不能重复连接" {
+		t.Fatalf("duplicate StartRoom(1001) = %q", got)
+	}
+	if got := a.StartRoom(1002); got != "连接成功" {
+		t.Fatalf("StartRoom(1002) = %q", got)
+	}
+	if got := a.StartRoom(404); got != "连接失败: 直播间不存在或未开播" {
+		t.Fatalf("StartRoom(404) = %q", got)
+	}
+
+	rooms := a.ListRooms()
+	if len(rooms) != 2 || rooms[0].LiveId != 1001 || rooms[1].LiveId != 1002 {
+		t.Fatalf("ListRooms = %+v", rooms)
+	}
+	status, err := a.RoomStatus(1002)
+	if err != nil || status.RoomId != mockserver.RoomId {
+		t.Fatalf("RoomStatus(1002) = %+v, %v", status, err)
+	}
+
+	if got := a.StopRoom(1001); got != "连接已断开" {
+		t.Fatalf("StopRoom(1001) = %q", got)
+	}
+	// 停止后保留直播间，统计仍可查询
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status, err := a.RoomStatus(1001)
+		if err != nil {
+			t.Fatalf("RoomStatus(1001) after StopRoom: %v", err)
+		}
+		if status.State == enums.LifecycleStopped {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("room 1001 state = %q after StopRoom", status.State)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := a.ProductTimeline(1001); err != nil {
+		t.Fatalf("ProductTimeline(1001) after StopRoom: %v", err)
+	}
+	if got := a.StartRoom(1001); got != "连接成功" {
+		t.Fatalf("StartRoom(1001) after StopRoom = %q", got)
+	}
+
+	if got := a.RemoveRoom(1001); got != "已清除" {
+		t.Fatalf("RemoveRoom(1001) = %q", got)
+	}
+	if _, err := a.RoomStatus(1001); err == nil {
+		t.Fatal("room 1001 still listed after RemoveRoom")
+	}
+	if got := a.RemoveRoom(1001); got != "直播间不存在" {
+		t.Fatalf("second RemoveRoom(1001) = %q", got)
+	}
+}
@@ -0,0 +1,230 @@
+// Package archive 将 websocket 收到的原始帧按会话写入紧凑的归档文件，便于排查解析问题与离线回放。
+//
+// 文件格式（整数均为小端或 varint）：
+//
+//	magic   [4]byte  "DYLA"
+//	version uint16
+//	hlen    uint32   header JSON 长度
+//	header  [hlen]byte
+//	records ...
+//
+// 每条记录为：
+//
+//	uvarint  帧长度 n
+//	varint   收到时间，相对上一条记录的毫秒差（第一条相对 header.StartedAt）
+//	[n]byte  原始 PushFrame 字节
+//
+// 直播间信息只写在 header 中，同一个文件中的帧都属于该直播间。
+package archive
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+const (
+	Version   = 1
+	Extension = ".dyla"
+
+	FailedToWriteArchiveError = "FailedToWriteArchiveError: %v"
+	FailedToReadArchiveError  = "FailedToReadArchiveError: %v"
+
+	maxFrameSize = 64 << 20
+)
+
+var (
+	magic = [4]byte{'D', 'Y', 'L', 'A'}
+
+	InvalidArchive     = errors.New("not a frame archive")
+	UnsupportedVersion = errors.New("unsupported archive version")
+	Truncated          = errors.New("archive truncated")
+)
+
+// Header 描述一次采集会话
+type Header struct {
+	Version          int
+	LiveId           uint64
+	RoomId           string
+	Signer           string
+	SignerVersion    string
+	CollectorVersion string
+	StartedAt        time.Time
+}
+
+// Frame 是归档中的一帧
+type Frame struct {
+	ReceivedAt time.Time
+	Data       []byte
+}
+
+// Writer 顺序写入帧，非并发安全
+type Writer struct {
+	file    *os.File
+	buf     *bufio.Writer
+	header  Header
+	last    time.Time
+	frames  int
+	scratch [2 * binary.MaxVarintLen64]byte
+}
+
+// Create 创建归档文件并写入 header
+func Create(path string, header Header) (*Writer, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf(FailedToWriteArchiveError, err)
+	}
+	header.Version = Version
+	if header.StartedAt.IsZero() {
+		header.StartedAt = time.Now()
+	}
+	w := &Writer{
+		file:   file,
+		buf:    bufio.NewWriter(file),
+		header: header,
+		last:   header.StartedAt,
+	}
+	if err := w.writeHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *Writer) writeHeader() error {
+	data, err := json.Marshal(w.header)
+	if err != nil {
+		return fmt.Errorf(FailedToWriteArchiveError, err)
+	}
+	var prefix [10]byte
+	copy(prefix[:4], magic[:])
+	binary.LittleEndian.PutUint16(prefix[4:6], Version)
+	binary.LittleEndian.PutUint32(prefix[6:10], uint32(len(data)))
+	if _, err := w.buf.Write(prefix[:]); err != nil {
+		return fmt.Errorf(FailedToWriteArchiveError, err)
+	}
+	if _, err := w.buf.Write(data); err != nil {
+		return fmt.Errorf(FailedToWriteArchiveError, err)
+	}
+	return w.flush()
+}
+
+// Write 追加一帧，写完即刷新到文件，进程异常退出时最多丢失最后一帧
+func (w *Writer) Write(receivedAt time.Time, data []byte) error {
+	n := binary.PutUvarint(w.scratch[:], uint64(len(data)))
+	n += binary.PutVarint(w.scratch[n:], receivedAt.Sub(w.last).Milliseconds())
+	if _, err := w.buf.Write(w.scratch[:n]); err != nil {
+		return fmt.Errorf(FailedToWriteArchiveError, err)
+	}
+	if _, err := w.buf.Write(data); err != nil {
+		return fmt.Errorf(FailedToWriteArchiveError, err)
+	}
+	// 按毫秒累计，避免多帧之间的舍入误差叠加
+	w.last = w.last.Add(time.Duration(receivedAt.Sub(w.last).Milliseconds()) * time.Millisecond)
+	w.frames++
+	return w.flush()
+}
+
+func (w *Writer) flush() error {
+	if err := w.buf.Flush(); err != nil {
+		return fmt.Errorf(FailedToWriteArchiveError, err)
+	}
+	return nil
+}
+
+func (w *Writer) Path() string {
+	return w.file.Name()
+}
+
+func (w *Writer) Frames() int {
+	return w.frames
+}
+
+func (w *Writer) Close() error {
+	if err := w.flush(); err != nil {
+		w.file.Close()
+		return err
+	}
+	return w.file.Close()
+}
+
+// Reader 顺序读取归档
+type Reader struct {
+	file   *os.File
+	buf    *bufio.Reader
+	header Header
+	last   time.Time
+}
+
+// Open 打开归档并读取 header
+func Open(path string) (*Reader, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf(FailedToReadArchiveError, err)
+	}
+	r := &Reader{file: file, buf: bufio.NewReader(file)}
+	if err := r.readHeader(); err != nil {
+		file.Close()
+		return nil, err
+	}
+	r.last = r.header.StartedAt
+	return r, nil
+}
+
+func (r *Reader) readHeader() error {
+	var prefix [10]byte
+	if _, err := io.ReadFull(r.buf, prefix[:]); err != nil {
+		return InvalidArchive
+	}
+	if [4]byte(prefix[:4]) != magic {
+		return InvalidArchive
+	}
+	if binary.LittleEndian.Uint16(prefix[4:6]) != Version {
+		return UnsupportedVersion
+	}
+	data := make([]byte, binary.LittleEndian.Uint32(prefix[6:10]))
+	if _, err := io.ReadFull(r.buf, data); err != nil {
+		return Truncated
+	}
+	if err := json.Unmarshal(data, &r.header); err != nil {
+		return fmt.Errorf(FailedToReadArchiveError, err)
+	}
+	return nil
+}
+
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next 返回下一帧，读完时返回 io.EOF，最后一帧不完整时返回 Truncated
+func (r *Reader) Next() (Frame, error) {
+	size, err := binary.ReadUvarint(r.buf)
+	if err == io.EOF {
+		return Frame{}, io.EOF
+	}
+	if err != nil {
+		return Frame{}, Truncated
+	}
+	if size > maxFrameSize {
+		return Frame{}, fmt.Errorf(FailedToReadArchiveError, fmt.Sprintf("frame of %d bytes", size))
+	}
+	delta, err := binary.ReadVarint(r.buf)
+	if err != nil {
+		return Frame{}, Truncated
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r.buf, data); err != nil {
+		return Frame{}, Truncated
+	}
+	r.last = r.last.Add(time.Duration(delta) * time.Millisecond)
+	return Frame{ReceivedAt: r.last, Data: data}, nil
+}
+
+func (r *Reader) Close() error {
+	return r.file.Close()
+}
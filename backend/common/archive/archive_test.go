@@ -0,0 +1,66 @@
+package archive
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "session"+Extension)
+	start := time.UnixMilli(1700000000000)
+	w, err := Create(path, Header{LiveId: 1, RoomId: "2", Signer: "embedded", StartedAt: start})
+	if err != nil {
+		t.Fatal(err)
+	}
+	frames := []Frame{
+		{ReceivedAt: start.Add(1500 * time.Millisecond), Data: []byte("first")},
+		{ReceivedAt: start.Add(1500 * time.Millisecond), Data: nil},
+		{ReceivedAt: start.Add(time.Hour), Data: make([]byte, 1<<16)},
+	}
+	for _, frame := range frames {
+		if err := w.Write(frame.ReceivedAt, frame.Data); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := r.Header(); h.Version != Version || h.RoomId != "2" || !h.StartedAt.Equal(start) {
+		t.Fatalf("header = %+v", h)
+	}
+	for i, want := range frames {
+		got, err := r.Next()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if !got.ReceivedAt.Equal(want.ReceivedAt) || len(got.Data) != len(want.Data) {
+			t.Fatalf("frame %d = %v/%d bytes, want %v/%d bytes", i, got.ReceivedAt, len(got.Data), want.ReceivedAt, len(want.Data))
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Fatalf("after last frame: %v, want io.EOF", err)
+	}
+	r.Close()
+
+	// 截掉最后一帧的末尾，应返回 Truncated 而不是 EOF
+	info, _ := os.Stat(path)
+	os.Truncate(path, info.Size()-1)
+	r, _ = Open(path)
+	defer r.Close()
+	var last error
+	for last == nil {
+		_, last = r.Next()
+	}
+	if !errors.Is(last, Truncated) {
+		t.Fatalf("truncated archive: %v", last)
+	}
+}
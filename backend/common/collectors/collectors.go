@@ -1,17 +1,25 @@
 package collectors
 
 import (
+	"context"
+	"douyinLiveCollectors/backend/common/archive"
+	"douyinLiveCollectors/backend/common/config"
 	"douyinLiveCollectors/backend/common/enums"
 	"douyinLiveCollectors/backend/common/handler"
 	"douyinLiveCollectors/backend/common/log"
+	"douyinLiveCollectors/backend/common/tracker"
 	"douyinLiveCollectors/backend/library/sign"
+	timeutil "douyinLiveCollectors/backend/library/time"
 	"errors"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"io/ioutil"
+	"math/rand"
 	"net/http"
 	"regexp"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,6 +32,14 @@ const (
 	FailedToGenerateSignatureError = "FailedToGenerateSignatureError: %v"
 	FailedToCreateRequestError     = "FailedToCreateRequestError: %v"
 	FailedToSendRequestError       = "FailedToSendRequestError: %v"
+	ReconnectError                 = "ReconnectError: attempt %d: %v"
+)
+
+const (
+	reconnectBaseDelay   = time.Second
+	reconnectMaxDelay    = time.Minute
+	maxReconnectAttempts = 10
+	lifecycleBufferSize  = 32
 )
 
 //const (
@@ -31,33 +47,182 @@ const (
 //	UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 //)
 
+// Start 返回的错误均包装自以下哨兵错误，可使用 errors.Is 判断失败原因
 var (
-	RoomIdNotFound = errors.New("roomId not found in response")
+	RoomIdNotFound  = errors.New("roomId not found in response")
+	TtwIdNotFound   = errors.New("ttwid cookie not found in response")
+	RequestFailed   = errors.New("request live room failed")
+	SignatureFailed = errors.New("generate signature failed")
+	DialFailed      = errors.New("websocket dial failed")
+	ViewerStopped   = errors.New("live viewer stopped")
 )
 
+// LifecycleEvent 描述连接状态的变化，例如断线、重连尝试及其结果
+type LifecycleEvent struct {
+	Type    string
+	LiveId  uint64
+	Attempt int
+	Delay   time.Duration
+	Error   string
+	Time    string
+}
+
 type LiveViewer struct {
-	liveId    uint64
-	ttwId     string
-	roomId    string
-	liveUrl   string
-	userAgent string
-	ws        *websocket.Conn
-	Out       chan handler.Result
-	//Out       map[string]chan handler.Result
+	liveId      uint64
+	ttwId       string
+	roomId      string
+	cfg         config.Config
+	signer      sign.Signer
+	recorder    *archive.Writer
+	recordPath  string
+	cursor      string
+	internalExt string
+	logger      *log.DefaultLogger
+	handler     *handler.Handler
+	products    *tracker.ProductTimeline
+	scoreboard  *tracker.Scoreboard
+	fanTickets  *tracker.FanTicketSeries
+	notices     *tracker.Notices
+	mu          sync.Mutex
+	ws          *conn
+	stopped     chan struct{}
+	// 心跳间隔(纳秒)，由 listen 写入、heartbeat 读取
+	heartbeatInterval atomic.Int64
+	heartbeatReset    chan struct{} // 间隔变化时通知 heartbeat 重新计时
+	stopOnce          sync.Once
+	outOnce           sync.Once
+	Out               chan handler.Event
+	Lifecycle         chan LifecycleEvent
+	//Out       map[string]chan handler.Event
 }
 
+// NewLiveViewer 使用当前全局配置创建 LiveViewer
 func NewLiveViewer(liveId uint64) *LiveViewer {
+	return NewLiveViewerWithConfig(liveId, config.Get())
+}
+
+func NewLiveViewerWithConfig(liveId uint64, cfg config.Config) *LiveViewer {
+	v := &LiveViewer{
+		liveId:         liveId,
+		cfg:            cfg,
+		logger:         log.NewRoomLogger(strconv.FormatUint(liveId, 10)),
+		stopped:        make(chan struct{}),
+		heartbeatReset: make(chan struct{}, 1),
+		Out:            make(chan handler.Event),
+		Lifecycle:      make(chan LifecycleEvent, lifecycleBufferSize),
+	}
+	v.handler = handler.NewHandler(v.logger, v.Out, handler.Options{
+		Workers:    v.cfg.DispatchWorkers,
+		BufferSize: v.cfg.OutputBuffer,
+		Policy:     v.cfg.OutputPolicy,
+		DedupSize:  v.cfg.DedupSize,
+		DedupTTL:   v.cfg.DedupTTLDuration(),
+		Catalog:    v.cfg.Catalog(),
+	})
+	v.products = tracker.NewProductTimeline()
+	v.scoreboard = tracker.NewScoreboard()
+	v.fanTickets = tracker.NewFanTicketSeries()
+	v.notices = tracker.NewNotices()
+	v.handler.Registry().On("", v.products.Observe)
+	v.handler.Registry().On(enums.WebcastMatchAgainstScoreMessage, v.scoreboard.Observe)
+	v.handler.Registry().On(enums.WebcastUpdateFanTicketMessage, v.fanTickets.Observe)
+	v.handler.Registry().On(enums.WebcastCommonTextMessage, v.notices.Observe)
+	return v
+}
+
+func (v *LiveViewer) LiveId() uint64 {
+	return v.liveId
+}
+
+func (v *LiveViewer) RoomId() string {
+	return v.roomId
+}
+
+// Registry 返回该直播间的解码器注册表，注册后对之后收到的消息生效
+func (v *LiveViewer) Registry() *handler.Registry {
+	return v.handler.Registry()
+}
+
+// OutputStats 返回输出缓冲的计数，消费者过慢时可据此观察丢弃情况
+func (v *LiveViewer) OutputStats() handler.OutputStats {
+	return v.handler.OutputStats()
+}
+
+// DriftReport 返回协议变化统计，见 handler.DriftReport
+func (v *LiveViewer) DriftReport() handler.DriftReport {
+	return v.handler.DriftReport()
+}
 
-	return &LiveViewer{
-		liveId:    liveId,
-		liveUrl:   enums.Url,
-		userAgent: enums.UserAgent,
-		Out:       make(chan handler.Result),
+// Products 返回本次会话的商品讲解时间线
+func (v *LiveViewer) Products() *tracker.ProductTimeline {
+	return v.products
+}
+
+// Scoreboard 返回体育赛事直播间的比分与比分历史
+func (v *LiveViewer) Scoreboard() *tracker.Scoreboard {
+	return v.scoreboard
+}
+
+// FanTickets 返回本场音浪的时间序列
+func (v *LiveViewer) FanTickets() *tracker.FanTicketSeries {
+	return v.fanTickets
+}
+
+// Notices 返回按场景归类的通用文本消息
+func (v *LiveViewer) Notices() *tracker.Notices {
+	return v.notices
+}
+
+// RecordPath 返回本次会话的帧归档文件，未开启录制时为空
+func (v *LiveViewer) RecordPath() string {
+	return v.recordPath
+}
+
+// SetSigner 替换配置中指定的签名后端，需在 Start 之前调用
+func (v *LiveViewer) SetSigner(signer sign.Signer) {
+	v.signer = signer
+}
+
+// Start 获取 ttwid、roomId 并建立 websocket 连接，任一步失败都会返回错误并关闭 Out。
+// 连接成功后 ctx 取消或调用 Stop 会断开连接、结束读协程，已收到的消息全部送出后关闭 Out，
+// 因此调用方需持续读取 Out 直到其关闭
+func (v *LiveViewer) Start(ctx context.Context) error {
+	if err := v.start(ctx); err != nil {
+		v.Stop()
+		v.handler.Close()
+		v.logger.Close()
+		v.closeOut()
+		return err
 	}
+	v.logger.Info("Websocket connected.")
+	v.emit(LifecycleEvent{Type: enums.LifecycleConnected})
+	v.openRecorder()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+			v.Stop()
+		case <-v.stopped:
+		}
+	}()
+	go v.listen(ctx)
+	return nil
 }
 
-func (v *LiveViewer) Start() {
-	v.setTtwId().setRoomID()
+func (v *LiveViewer) start(ctx context.Context) error {
+	if v.signer == nil {
+		signer, err := sign.New(v.cfg.SignerOptions())
+		if err != nil {
+			return fmt.Errorf("%w: %v", SignatureFailed, err)
+		}
+		v.signer = signer
+	}
+	if err := v.setTtwId(ctx); err != nil {
+		return err
+	}
+	if err := v.setRoomID(ctx); err != nil {
+		return err
+	}
 	//wss := fmt.Sprintf("wss://webcast5-ws-web-hl.douyin.com/webcast/im/push/v2/?app_name=douyin_web"+
 	//	"&version_code=180800&webcast_sdk_version=1.0.14-beta.0"+
 	//	"&update_version_code=1.0.14-beta.0&compress=gzip&device_platform=web&cookie_enabled=true"+
@@ -74,62 +239,187 @@ func (v *LiveViewer) Start() {
 	//	"&user_unique_id=7319483754668557238&im_path=/webcast/im/fetch/&identity=audience"+
 	//	"&need_persist_msg_count=15&insert_task_id=&live_reason=&room_id=%s&heartbeatDuration=0", v.roomId, v.roomId)
 
-	wss := fmt.Sprintf(enums.WssUrl, v.roomId, v.roomId)
+	if err := v.connect(ctx); err != nil {
+		v.logger.Info(WebSocketConnectError, err.Error())
+		return err
+	}
+	return nil
+}
 
-	signature, err := sign.GenerateSignature(wss)
+// connect 使用最近一次收到的 cursor / internalExt 构造 wss 地址并建立连接
+func (v *LiveViewer) connect(ctx context.Context) error {
+	wss := v.wssUrl()
+
+	signature, err := sign.GenerateSignature(ctx, v.signer, wss)
 	if err != nil {
-		log.Info(FailedToGenerateSignatureError, err.Error())
+		v.logger.Info(FailedToGenerateSignatureError, err.Error())
+		return fmt.Errorf("%w: %v", SignatureFailed, err)
 	}
 
 	wss += fmt.Sprintf("&signature=%v", signature)
 
 	headers := http.Header{
 		"Cookie":     []string{fmt.Sprintf("ttwid=%s", v.ttwId)},
-		"User-Agent": []string{v.userAgent},
+		"User-Agent": []string{v.cfg.UserAgent},
 	}
 
-	var dialer websocket.Dialer
-	v.ws, _, err = dialer.Dial(wss, headers)
+	dialer := websocket.Dialer{HandshakeTimeout: v.cfg.DialTimeoutDuration()}
+	ws, _, err := dialer.DialContext(ctx, wss, headers)
 	if err != nil {
-		v.Stop()
-		log.Info(WebSocketConnectError, err.Error())
+		return fmt.Errorf("%w: %v", DialFailed, err)
 	}
-	log.Info("Websocket connected.")
-	go v.listen()
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.isStopped() {
+		ws.Close()
+		return ViewerStopped
+	}
+	v.ws = newConn(ws)
+	go v.heartbeat(v.ws)
+	return nil
 }
 
-func (v *LiveViewer) listen() {
-	defer v.Stop()
+func (v *LiveViewer) wssUrl() string {
+	return buildWssUrl(v.cfg, v.roomId, v.cursor, v.internalExt)
+}
+
+func (v *LiveViewer) listen(ctx context.Context) {
+	defer func() {
+		v.Stop()
+		v.handler.Wait()
+		v.handler.Close()
+		v.closeRecorder()
+		v.logger.Close()
+		v.closeOut()
+	}()
 	for {
-		_, messages, err := v.ws.ReadMessage()
+		v.mu.Lock()
+		ws := v.ws
+		v.mu.Unlock()
+
+		_, messages, err := ws.ReadMessage()
 		if err != nil {
-			log.Info(WebSocketError, err.Error())
-			v.Stop()
-			break
+			if v.isStopped() {
+				return
+			}
+			v.logger.Info(WebSocketError, err.Error())
+			v.emit(LifecycleEvent{Type: enums.LifecycleDisconnected, Error: err.Error()})
+			ws.Close()
+			if !v.reconnect(ctx) {
+				return
+			}
+			continue
+		}
+		v.record(time.Now(), messages)
+		resp := v.handler.Handle(ws, messages)
+		if resp == nil {
+			continue
+		}
+		v.setHeartbeatInterval(resp.GetHeartbeatDuration())
+		if resp.GetCursor() != "" {
+			v.cursor = resp.GetCursor()
+			v.internalExt = resp.GetInternalExt()
+		}
+		if handler.IsLiveEnded(resp) {
+			return
+		}
+	}
+}
+
+// reconnect 按指数退避加随机抖动重连，成功返回 true；超过最大次数或已停止返回 false
+func (v *LiveViewer) reconnect(ctx context.Context) bool {
+	for attempt := 1; attempt <= maxReconnectAttempts; attempt++ {
+		delay := backoff(attempt)
+		v.emit(LifecycleEvent{Type: enums.LifecycleReconnecting, Attempt: attempt, Delay: delay})
+
+		select {
+		case <-time.After(delay):
+		case <-v.stopped:
+			return false
 		}
-		handler.Handler(v.ws, messages, v.Out)
+
+		if err := v.connect(ctx); err != nil {
+			if v.isStopped() {
+				return false
+			}
+			v.logger.Info(ReconnectError, attempt, err.Error())
+			v.emit(LifecycleEvent{Type: enums.LifecycleReconnectFailed, Attempt: attempt, Error: err.Error()})
+			continue
+		}
+		v.logger.Info("Websocket reconnected after %d attempt(s).", attempt)
+		v.emit(LifecycleEvent{Type: enums.LifecycleReconnected, Attempt: attempt})
+		return true
 	}
+	return false
 }
 
+// Stop 断开连接并结束读协程，已收到的消息仍会送出，见 Start
 func (v *LiveViewer) Stop() {
-	if v.ws != nil {
-		v.ws.Close()
+	v.stopOnce.Do(func() {
+		v.emit(LifecycleEvent{Type: enums.LifecycleStopped})
+		v.mu.Lock()
+		close(v.stopped)
+		if v.ws != nil {
+			v.ws.Close()
+		}
+		close(v.Lifecycle)
+		v.mu.Unlock()
+		v.logger.Info("WebSocket connection closed.")
+	})
+}
+
+func (v *LiveViewer) closeOut() {
+	v.outOnce.Do(func() {
+		close(v.Out)
+	})
+}
+
+func (v *LiveViewer) isStopped() bool {
+	select {
+	case <-v.stopped:
+		return true
+	default:
+		return false
+	}
+}
+
+// emit 非阻塞地投递生命周期事件，消费方处理不及时时丢弃；Stop 之后 Lifecycle 会被关闭
+func (v *LiveViewer) emit(event LifecycleEvent) {
+	event.LiveId = v.liveId
+	event.Time = timeutil.Now()
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.isStopped() {
+		return
+	}
+	select {
+	case v.Lifecycle <- event:
+	default:
 	}
-	log.Info("WebSocket connection closed.")
 }
 
-func (v *LiveViewer) setRoomID() *LiveViewer {
-	url := v.liveUrl + strconv.FormatUint(v.liveId, 10)
+func backoff(attempt int) time.Duration {
+	delay := reconnectBaseDelay << (attempt - 1)
+	if delay <= 0 || delay > reconnectMaxDelay {
+		delay = reconnectMaxDelay
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+func (v *LiveViewer) setRoomID(ctx context.Context) error {
+	url := v.cfg.WebHost + strconv.FormatUint(v.liveId, 10)
 
 	headers := map[string]string{
-		"User-Agent": v.userAgent,
+		"User-Agent": v.cfg.UserAgent,
 		"Cookie":     fmt.Sprintf("ttwid=%v; msToken=%v; __ac_nonce=0123407cc00a9e438deb4", v.ttwId, sign.GenerateMsToken()),
 	}
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	req, err := http.NewRequest("GET", url, nil)
+	client := &http.Client{Timeout: v.cfg.RequestTimeoutDuration()}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		log.Info(FailedToCreateRequestError, err.Error())
+		v.logger.Info(FailedToCreateRequestError, err.Error())
+		return fmt.Errorf("%w: %v", RequestFailed, err)
 	}
 	for k, v := range headers {
 		req.Header.Set(k, v)
@@ -137,42 +427,52 @@ func (v *LiveViewer) setRoomID() *LiveViewer {
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Info(FailedToRequestLiveRoomError, err.Error())
+		v.logger.Info(FailedToRequestLiveRoomError, err.Error())
+		return fmt.Errorf("%w: %v", RequestFailed, err)
 	}
 	defer resp.Body.Close()
 
 	body, err := ioutil.ReadAll(resp.Body)
 	if err != nil {
-		log.Info(FailedToReadResponseBodyError, err.Error())
+		v.logger.Info(FailedToReadResponseBodyError, err.Error())
+		return fmt.Errorf("%w: %v", RequestFailed, err)
 	}
 
 	// 使用正则表达式查找 roomID
 	roomIdrRe := regexp.MustCompile(`roomId\\":\\"(\d+)\\"`)
 	matches := roomIdrRe.FindStringSubmatch(string(body))
 	if len(matches) < 2 {
-		log.Info(RoomIdNotFound.Error())
+		v.logger.Info(RoomIdNotFound.Error())
+		return RoomIdNotFound
 	}
 
 	v.roomId = matches[1]
-	return v
+	return nil
 }
 
-func (v *LiveViewer) setTtwId() *LiveViewer {
-	client := &http.Client{}
-	req, _ := http.NewRequest("GET", v.liveUrl, nil)
+func (v *LiveViewer) setTtwId(ctx context.Context) error {
+	client := &http.Client{Timeout: v.cfg.RequestTimeoutDuration()}
+	req, err := http.NewRequestWithContext(ctx, "GET", v.cfg.WebHost, nil)
+	if err != nil {
+		v.logger.Info(FailedToCreateRequestError, err.Error())
+		return fmt.Errorf("%w: %v", RequestFailed, err)
+	}
 
-	req.Header.Set("User-Agent", "v.userAgent")
+	req.Header.Set("User-Agent", v.cfg.UserAgent)
 
 	resp, err := client.Do(req)
 	if err != nil {
-		log.Info(FailedToSendRequestError, err.Error())
+		v.logger.Info(FailedToSendRequestError, err.Error())
+		return fmt.Errorf("%w: %v", RequestFailed, err)
 	}
+	defer resp.Body.Close()
+
 	for _, cookie := range resp.Cookies() {
 		if cookie.Name == "ttwid" {
 			v.ttwId = cookie.Value
-		} else {
-			log.Info(FailedToGetTtwIdError, err.Error())
+			return nil
 		}
 	}
-	return v
+	v.logger.Info(FailedToGetTtwIdError, TtwIdNotFound.Error())
+	return TtwIdNotFound
 }
@@ -0,0 +1,237 @@
+package collectors_test
+
+import (
+	"context"
+	"douyinLiveCollectors/backend/common/archive"
+	"douyinLiveCollectors/backend/common/collectors"
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/handler"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const liveId = 123456
+
+func TestMain(m *testing.M) {
+	// 日志写在工作目录的 ./logs 下，测试时切到临时目录
+	dir, err := os.MkdirTemp("", "collectors-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newServer(t *testing.T, fixtures ...*message.Response) *mockserver.Server {
+	t.Helper()
+	s := mockserver.New(fixtures...)
+	s.Rooms["123456"] = mockserver.RoomId
+	t.Cleanup(s.Close)
+	return s
+}
+
+func waitResult(t *testing.T, out <-chan handler.Event, substr string) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case result, ok := <-out:
+			if !ok {
+				t.Fatalf("Out closed before %q arrived", substr)
+			}
+			if strings.Contains(handler.FormatText(result), substr) {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %q", substr)
+		}
+	}
+}
+
+func waitClosed(t *testing.T, out <-chan handler.Event) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case _, ok := <-out:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("Out was not closed")
+		}
+	}
+}
+
+func waitLifecycle(t *testing.T, events <-chan collectors.LifecycleEvent, eventType string) {
+	t.Helper()
+	timeout := time.After(10 * time.Second)
+	for {
+		select {
+		case event, ok := <-events:
+			if !ok {
+				t.Fatalf("Lifecycle closed before %s", eventType)
+			}
+			if event.Type == eventType {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timed out waiting for %s", eventType)
+		}
+	}
+}
+
+func TestLiveViewerEndToEnd(t *testing.T) {
+	user := mockserver.NewUser(42, "tester")
+	s := newServer(t, mockserver.NewResponse(1, mockserver.Chat(user, "hello"), mockserver.Gift(user, "小心心", 3)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := collectors.NewLiveViewerWithConfig(liveId, s.Config())
+	if err := v.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if v.RoomId() != mockserver.RoomId {
+		t.Fatalf("RoomId = %s, want %s", v.RoomId(), mockserver.RoomId)
+	}
+
+	waitResult(t, v.Out, "hello")
+	if !s.WaitAcks(1) {
+		t.Fatal("no ack received")
+	}
+	for _, ack := range s.Acks() {
+		if !ack.Valid {
+			t.Fatalf("ack with unexpected logId/internalExt: %+v", ack)
+		}
+	}
+
+	cancel()
+	waitClosed(t, v.Out)
+}
+
+func TestLiveViewerRoomNotFound(t *testing.T) {
+	s := newServer(t)
+	cfg := s.Config()
+
+	v := collectors.NewLiveViewerWithConfig(999, cfg)
+	err := v.Start(context.Background())
+	if !errors.Is(err, collectors.RoomIdNotFound) {
+		t.Fatalf("Start error = %v, want RoomIdNotFound", err)
+	}
+	waitClosed(t, v.Out)
+}
+
+func TestLiveViewerReconnectResumesCursor(t *testing.T) {
+	user := mockserver.NewUser(42, "tester")
+	s := newServer(t, mockserver.NewResponse(1, mockserver.Chat(user, "before")))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := collectors.NewLiveViewerWithConfig(liveId, s.Config())
+	if err := v.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitResult(t, v.Out, "before")
+
+	s.DropConnections()
+	waitLifecycle(t, v.Lifecycle, enums.LifecycleReconnected)
+
+	// 重连后服务端会补发 "before"，去重后只应收到新消息
+	if err := s.Push(mockserver.NewResponse(2, mockserver.Chat(user, "after"))); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(5 * time.Second)
+	for after := false; !after || v.OutputStats().Duplicates == 0; {
+		select {
+		case event := <-v.Out:
+			text := handler.FormatText(event)
+			if strings.Contains(text, "before") {
+				t.Fatal("replayed message was emitted twice")
+			}
+			after = after || strings.Contains(text, "after")
+		case <-time.After(10 * time.Millisecond):
+		case <-timeout:
+			t.Fatal("timed out waiting for the new message and the deduplicated replay")
+		}
+	}
+
+	connections := s.Connections()
+	if len(connections) < 2 {
+		t.Fatalf("expected a second connection, got %d", len(connections))
+	}
+	if cursor := connections[1].Get("cursor"); cursor != "mock-cursor-1" {
+		t.Fatalf("resumed cursor = %q, want mock-cursor-1", cursor)
+	}
+	if ext := connections[1].Get("internal_ext"); ext != "mock-internal-ext-1" {
+		t.Fatalf("resumed internal_ext = %q, want mock-internal-ext-1", ext)
+	}
+	want := strconv.FormatInt(s.Config().HeartbeatIntervalDuration().Milliseconds(), 10)
+	if hb := connections[0].Get("heartbeatDuration"); hb != want {
+		t.Fatalf("heartbeatDuration = %q, want %q", hb, want)
+	}
+}
+
+func TestLiveViewerHeartbeat(t *testing.T) {
+	resp := mockserver.NewResponse(1)
+	resp.HeartbeatDuration = 1000
+	s := newServer(t, resp)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	v := collectors.NewLiveViewerWithConfig(liveId, s.Config())
+	if err := v.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	go func() {
+		for range v.Out {
+		}
+	}()
+
+	// 配置的间隔为 10s，3.5s 内收到多个心跳说明使用了服务端下发的 1s
+	time.Sleep(3500 * time.Millisecond)
+	if n := s.Heartbeats(); n < 2 {
+		t.Fatalf("Heartbeats = %d after 3.5s, want >= 2", n)
+	}
+
+	// 连接假死超过 3 个心跳间隔后应断开并重连
+	s.Stall()
+	waitLifecycle(t, v.Lifecycle, enums.LifecycleReconnected)
+	if n := len(s.Connections()); n < 2 {
+		t.Fatalf("connections = %d, want a reconnect", n)
+	}
+}
+
+func TestLiveViewerStopsWhenLiveEnded(t *testing.T) {
+	s := newServer(t, mockserver.NewResponse(1, mockserver.LiveEnded()))
+	cfg := s.Config()
+	cfg.RecordDir = t.TempDir()
+
+	v := collectors.NewLiveViewerWithConfig(liveId, cfg)
+	if err := v.Start(context.Background()); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	waitResult(t, v.Out, "已结束")
+	waitClosed(t, v.Out)
+
+	r, err := archive.Open(v.RecordPath())
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer r.Close()
+	if header := r.Header(); header.LiveId != liveId || header.RoomId != mockserver.RoomId || header.Signer == "" {
+		t.Fatalf("archive header = %+v", header)
+	}
+	if _, err := r.Next(); err != nil {
+		t.Fatalf("first recorded frame: %v", err)
+	}
+}
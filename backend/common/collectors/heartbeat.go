@@ -0,0 +1,121 @@
+package collectors
+
+import (
+	"douyinLiveCollectors/backend/common/handler"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	HeartbeatTimeoutError = "HeartbeatTimeoutError: no data for %v"
+
+	minHeartbeatInterval   = time.Second
+	heartbeatTimeoutFactor = 3
+)
+
+// conn 包装一次 websocket 连接：串行化写操作（ack 与心跳并发写），并记录最近一次收到数据的时间
+type conn struct {
+	*websocket.Conn
+	writeMu   sync.Mutex
+	lastSeen  atomic.Int64
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newConn(ws *websocket.Conn) *conn {
+	c := &conn{Conn: ws, done: make(chan struct{})}
+	c.touch()
+	ws.SetPongHandler(func(string) error {
+		c.touch()
+		return nil
+	})
+	return c
+}
+
+func (c *conn) WriteMessage(messageType int, data []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.Conn.WriteMessage(messageType, data)
+}
+
+func (c *conn) ReadMessage() (int, []byte, error) {
+	messageType, data, err := c.Conn.ReadMessage()
+	if err == nil {
+		c.touch()
+	}
+	return messageType, data, err
+}
+
+func (c *conn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.done)
+	})
+	return c.Conn.Close()
+}
+
+func (c *conn) touch() {
+	c.lastSeen.Store(time.Now().UnixNano())
+}
+
+func (c *conn) idle() time.Duration {
+	return time.Since(time.Unix(0, c.lastSeen.Load()))
+}
+
+// setHeartbeatInterval 记录服务端在 Response.heartbeatDuration 中下发的心跳间隔(毫秒)
+func (v *LiveViewer) setHeartbeatInterval(ms uint64) {
+	if ms == 0 {
+		return
+	}
+	interval := time.Duration(ms) * time.Millisecond
+	if interval < minHeartbeatInterval {
+		interval = minHeartbeatInterval
+	}
+	if time.Duration(v.heartbeatInterval.Swap(int64(interval))) != interval {
+		select {
+		case v.heartbeatReset <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (v *LiveViewer) getHeartbeatInterval() time.Duration {
+	if interval := time.Duration(v.heartbeatInterval.Load()); interval > 0 {
+		return interval
+	}
+	return v.cfg.HeartbeatIntervalDuration()
+}
+
+// heartbeat 按服务端下发的间隔发送心跳帧；超过 heartbeatTimeoutFactor 个间隔没有收到任何数据时
+// 关闭连接，由 listen 走重连流程
+func (v *LiveViewer) heartbeat(c *conn) {
+	interval := v.getHeartbeatInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-v.heartbeatReset:
+			// 连接建立后才收到服务端下发的间隔，立即按新间隔计时
+			interval = v.getHeartbeatInterval()
+			ticker.Reset(interval)
+			continue
+		case <-ticker.C:
+		}
+
+		timeout := heartbeatTimeoutFactor * v.getHeartbeatInterval()
+		if idle := c.idle(); idle > timeout {
+			v.logger.Info(HeartbeatTimeoutError, idle.Round(time.Second))
+			c.Close()
+			return
+		}
+
+		if err := handler.SendHeartbeat(c); err != nil {
+			v.logger.Info(handler.SendHeartbeatError, err.Error())
+		}
+	}
+}
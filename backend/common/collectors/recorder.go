@@ -0,0 +1,65 @@
+package collectors
+
+import (
+	"douyinLiveCollectors/backend/common/archive"
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/library/sign"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	FailedToRecordFrameError = "FailedToRecordFrameError: %v"
+)
+
+// openRecorder 在配置了 recordDir 时为本次会话创建归档文件，失败只记录日志，不影响采集
+func (v *LiveViewer) openRecorder() {
+	if v.cfg.RecordDir == "" {
+		return
+	}
+	if err := os.MkdirAll(v.cfg.RecordDir, 0755); err != nil {
+		v.logger.Error(FailedToRecordFrameError, err)
+		return
+	}
+	now := time.Now()
+	name := fmt.Sprintf("%d_%s_%s%s", v.liveId, v.roomId, now.Format("20060102-150405"), archive.Extension)
+	recorder, err := archive.Create(filepath.Join(v.cfg.RecordDir, name), archive.Header{
+		LiveId:           v.liveId,
+		RoomId:           v.roomId,
+		Signer:           v.signer.Name(),
+		SignerVersion:    sign.ScriptVersion,
+		CollectorVersion: enums.CollectorVersion,
+		StartedAt:        now,
+	})
+	if err != nil {
+		v.logger.Error(FailedToRecordFrameError, err)
+		return
+	}
+	v.recorder = recorder
+	v.recordPath = recorder.Path()
+	v.logger.Info("Recording frames to %s", recorder.Path())
+}
+
+// record 只在读协程中调用；写入失败后停止录制
+func (v *LiveViewer) record(receivedAt time.Time, frame []byte) {
+	if v.recorder == nil {
+		return
+	}
+	if err := v.recorder.Write(receivedAt, frame); err != nil {
+		v.logger.Error(FailedToRecordFrameError, err)
+		v.closeRecorder()
+	}
+}
+
+func (v *LiveViewer) closeRecorder() {
+	if v.recorder == nil {
+		return
+	}
+	if err := v.recorder.Close(); err != nil {
+		v.logger.Error(FailedToRecordFrameError, err)
+	}
+	v.logger.Info("Recorded %d frames to %s", v.recorder.Frames(), v.recorder.Path())
+	v.recorder = nil
+}
@@ -0,0 +1,70 @@
+package collectors
+
+import (
+	"douyinLiveCollectors/backend/common/config"
+	"douyinLiveCollectors/backend/common/enums"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// buildWssUrl 根据配置拼接推送地址。cursor / internalExt 为空时（首次连接）按当前时间生成，
+// 重连时传入最近一次 Response 中的值以便从断点继续
+func buildWssUrl(cfg config.Config, roomId, cursor, internalExt string) string {
+	now := time.Now().UnixMilli()
+	if cursor == "" {
+		cursor = fmt.Sprintf("d-1_u-1_fh-0_t-%d_r-1", now)
+	}
+	if internalExt == "" {
+		internalExt = fmt.Sprintf("internal_src:dim|wss_push_room_id:%s|wss_push_did:%s"+
+			"|first_req_ms:%d|fetch_time:%d|seq:1|wss_info:0-%d-0-0|wrds_v:0",
+			roomId, cfg.DeviceId, now, now, now)
+	}
+
+	params := [][2]string{
+		{"app_name", "douyin_web"},
+		{"version_code", cfg.VersionCode},
+		{"webcast_sdk_version", cfg.SdkVersion},
+		{"update_version_code", cfg.SdkVersion},
+		{"compress", "gzip"},
+		{"device_platform", "web"},
+		{"cookie_enabled", "true"},
+		{"screen_width", "1536"},
+		{"screen_height", "864"},
+		{"browser_language", "zh-CN"},
+		{"browser_platform", "Win32"},
+		{"browser_name", "Mozilla"},
+		{"browser_version", strings.TrimPrefix(cfg.UserAgent, "Mozilla/")},
+		{"browser_online", "true"},
+		{"tz_name", "Asia/Shanghai"},
+		{"cursor", cursor},
+		{"internal_ext", internalExt},
+		{"host", strings.TrimRight(cfg.WebHost, "/")},
+		{"aid", "6383"},
+		{"live_id", "1"},
+		{"did_rule", "3"},
+		{"endpoint", "live_pc"},
+		{"support_wrds", "1"},
+		{"user_unique_id", cfg.DeviceId},
+		{"im_path", "/webcast/im/fetch/"},
+		{"identity", "audience"},
+		{"need_persist_msg_count", "15"},
+		{"insert_task_id", ""},
+		{"live_reason", ""},
+		{"room_id", roomId},
+		{"heartbeatDuration", strconv.FormatInt(cfg.HeartbeatIntervalDuration().Milliseconds(), 10)},
+	}
+
+	var query strings.Builder
+	for i, param := range params {
+		if i > 0 {
+			query.WriteByte('&')
+		}
+		query.WriteString(param[0])
+		query.WriteByte('=')
+		query.WriteString(url.QueryEscape(param[1]))
+	}
+	return cfg.WssHost + enums.WssPath + "?" + query.String()
+}
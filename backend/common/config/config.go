@@ -0,0 +1,315 @@
+package config
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/protodump"
+	"douyinLiveCollectors/backend/library/sign"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultPath = "./config.json"
+	envPrefix   = "DOUYIN_"
+)
+
+const (
+	FailedToReadConfigError  = "FailedToReadConfigError: %v"
+	FailedToParseConfigError = "FailedToParseConfigError: %v"
+	InvalidEnvError          = "InvalidEnvError: %s=%q"
+	InvalidConfigError       = "InvalidConfigError: %v"
+)
+
+var (
+	current = Default()
+	path    = DefaultPath
+	mutex   sync.RWMutex
+)
+
+// Config 汇总采集器依赖的可变参数。加载顺序：默认值 < 配置文件 < 环境变量
+type Config struct {
+	WebHost           string   `json:"webHost"`
+	WssHost           string   `json:"wssHost"`
+	UserAgent         string   `json:"userAgent"`
+	DeviceId          string   `json:"deviceId"`
+	VersionCode       string   `json:"versionCode"`
+	SdkVersion        string   `json:"sdkVersion"`
+	RequestTimeout    int      `json:"requestTimeout"`    // 秒
+	DialTimeout       int      `json:"dialTimeout"`       // 秒
+	HeartbeatInterval int      `json:"heartbeatInterval"` // 秒，服务端未下发 heartbeatDuration 时使用
+	DispatchWorkers   int      `json:"dispatchWorkers"`   // 每个直播间并行解码消息的协程数
+	OutputBuffer      int      `json:"outputBuffer"`      // 每个直播间输出缓冲的容量
+	OutputPolicy      string   `json:"outputPolicy"`      // 缓冲区满时的策略：block / drop-oldest / drop-newest / sample
+	DedupSize         int      `json:"dedupSize"`         // 按 msgId 去重的窗口大小，< 0 时不去重
+	DedupTTL          int      `json:"dedupTTL"`          // 秒，去重窗口的时长
+	RecordDir         string   `json:"recordDir"`         // 非空时将收到的原始帧按会话归档到该目录
+	CatalogDir        string   `json:"catalogDir"`        // 非空时将未知方法的消息按 wire 格式解析，按方法汇总到该目录
+	Signers           []string `json:"signers"`           // 签名后端回退链：embedded / node / remote，为空时按 signerPath 推断
+	SignerPath        string   `json:"signerPath"`        // node 后端执行的脚本
+	SignerUrl         string   `json:"signerUrl"`         // remote 后端的签名服务地址
+	SignTimeout       int      `json:"signTimeout"`       // 秒，单个后端的超时
+	SignCacheTTL      int      `json:"signCacheTTL"`      // 秒，按 X-MS-STUB 缓存签名结果，0 表示不缓存
+}
+
+func Default() Config {
+	return Config{
+		WebHost:           enums.Url,
+		WssHost:           enums.WssHost,
+		UserAgent:         enums.UserAgent,
+		DeviceId:          enums.DeviceId,
+		VersionCode:       enums.VersionCode,
+		SdkVersion:        enums.SdkVersion,
+		RequestTimeout:    10,
+		DialTimeout:       10,
+		HeartbeatInterval: 10,
+		DispatchWorkers:   4,
+		OutputBuffer:      1024,
+		OutputPolicy:      "sample",
+		DedupSize:         10000,
+		DedupTTL:          600,
+		SignTimeout:       5,
+		SignCacheTTL:      600,
+	}
+}
+
+// Load 读取配置文件（不存在时使用默认值）并叠加环境变量，校验通过后成为当前配置
+func Load(file string) (Config, error) {
+	cfg := Default()
+	data, err := os.ReadFile(file)
+	if err != nil && !os.IsNotExist(err) {
+		return cfg, fmt.Errorf(FailedToReadConfigError, err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &cfg); err != nil {
+			return cfg, fmt.Errorf(FailedToParseConfigError, err)
+		}
+	}
+	if err := applyEnv(&cfg); err != nil {
+		return cfg, err
+	}
+	cfg = cfg.normalize()
+	if err := cfg.Validate(); err != nil {
+		return cfg, fmt.Errorf(InvalidConfigError, err)
+	}
+
+	mutex.Lock()
+	current = cfg
+	path = file
+	mutex.Unlock()
+	return cfg, nil
+}
+
+// Save 校验并替换当前配置，同时写回最近一次 Load 的文件
+func Save(cfg Config) error {
+	cfg = cfg.normalize()
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return err
+	}
+	current = cfg
+	return nil
+}
+
+// Set 替换当前配置但不写回文件，用于测试或临时覆盖
+func Set(cfg Config) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	current = cfg.normalize()
+}
+
+// Get 返回当前配置的副本
+func Get() Config {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return current
+}
+
+func (c Config) Validate() error {
+	if !strings.HasPrefix(c.WebHost, "http://") && !strings.HasPrefix(c.WebHost, "https://") {
+		return errors.New("webHost must start with http:// or https://")
+	}
+	if !strings.HasPrefix(c.WssHost, "ws://") && !strings.HasPrefix(c.WssHost, "wss://") {
+		return errors.New("wssHost must start with ws:// or wss://")
+	}
+	if c.UserAgent == "" {
+		return errors.New("userAgent is required")
+	}
+	if c.RequestTimeout <= 0 || c.DialTimeout <= 0 || c.HeartbeatInterval <= 0 || c.SignTimeout <= 0 {
+		return errors.New("timeouts must be positive")
+	}
+	switch c.OutputPolicy {
+	case "block", "drop-oldest", "drop-newest", "sample":
+	default:
+		return fmt.Errorf("unknown outputPolicy %q", c.OutputPolicy)
+	}
+	if c.SignCacheTTL < 0 {
+		return errors.New("signCacheTTL must not be negative")
+	}
+	for _, backend := range c.Signers {
+		switch strings.TrimSpace(backend) {
+		case sign.BackendEmbedded:
+		case sign.BackendNode:
+			if c.SignerPath == "" {
+				return errors.New("signerPath is required by the node signer")
+			}
+		case sign.BackendRemote:
+			if c.SignerUrl == "" {
+				return errors.New("signerUrl is required by the remote signer")
+			}
+		default:
+			return fmt.Errorf("unknown signer %q", backend)
+		}
+	}
+	return nil
+}
+
+// SignerOptions 将配置转换为 sign.New 的参数。
+// 未配置 signers 时兼容旧配置：设置了 signerPath 则优先使用 node，失败后回退到内嵌脚本
+func (c Config) SignerOptions() sign.Options {
+	backends := c.Signers
+	if len(backends) == 0 {
+		backends = []string{sign.BackendEmbedded}
+		if c.SignerPath != "" {
+			backends = []string{sign.BackendNode, sign.BackendEmbedded}
+		}
+	}
+	return sign.Options{
+		Backends:   backends,
+		ScriptPath: c.SignerPath,
+		RemoteUrl:  c.SignerUrl,
+		Timeout:    time.Duration(c.SignTimeout) * time.Second,
+		CacheTTL:   time.Duration(c.SignCacheTTL) * time.Second,
+	}
+}
+
+// Catalog 返回未知消息目录，未配置 catalogDir 时返回 nil
+func (c Config) Catalog() *protodump.Catalog {
+	if c.CatalogDir == "" {
+		return nil
+	}
+	return protodump.OpenCatalog(c.CatalogDir)
+}
+
+func (c Config) RequestTimeoutDuration() time.Duration {
+	return time.Duration(c.RequestTimeout) * time.Second
+}
+
+func (c Config) DialTimeoutDuration() time.Duration {
+	return time.Duration(c.DialTimeout) * time.Second
+}
+
+func (c Config) DedupTTLDuration() time.Duration {
+	return time.Duration(c.DedupTTL) * time.Second
+}
+
+func (c Config) HeartbeatIntervalDuration() time.Duration {
+	return time.Duration(c.HeartbeatInterval) * time.Second
+}
+
+// normalize 补齐缺省值，并保证 WebHost 以 / 结尾、WssHost 不以 / 结尾
+func (c Config) normalize() Config {
+	def := Default()
+	if c.WebHost == "" {
+		c.WebHost = def.WebHost
+	}
+	if c.WssHost == "" {
+		c.WssHost = def.WssHost
+	}
+	if c.UserAgent == "" {
+		c.UserAgent = def.UserAgent
+	}
+	if c.DeviceId == "" {
+		c.DeviceId = def.DeviceId
+	}
+	if c.VersionCode == "" {
+		c.VersionCode = def.VersionCode
+	}
+	if c.SdkVersion == "" {
+		c.SdkVersion = def.SdkVersion
+	}
+	if c.DispatchWorkers <= 0 {
+		c.DispatchWorkers = def.DispatchWorkers
+	}
+	if c.OutputBuffer <= 0 {
+		c.OutputBuffer = def.OutputBuffer
+	}
+	if c.OutputPolicy == "" {
+		c.OutputPolicy = def.OutputPolicy
+	}
+	if c.DedupSize == 0 {
+		c.DedupSize = def.DedupSize
+	}
+	if c.DedupTTL <= 0 {
+		c.DedupTTL = def.DedupTTL
+	}
+	if c.SignTimeout == 0 {
+		c.SignTimeout = def.SignTimeout
+	}
+	c.WebHost = strings.TrimRight(c.WebHost, "/") + "/"
+	c.WssHost = strings.TrimRight(c.WssHost, "/")
+	return c
+}
+
+func applyEnv(c *Config) error {
+	strs := map[string]*string{
+		"WEB_HOST":      &c.WebHost,
+		"WSS_HOST":      &c.WssHost,
+		"USER_AGENT":    &c.UserAgent,
+		"DEVICE_ID":     &c.DeviceId,
+		"VERSION_CODE":  &c.VersionCode,
+		"SDK_VERSION":   &c.SdkVersion,
+		"SIGNER_PATH":   &c.SignerPath,
+		"SIGNER_URL":    &c.SignerUrl,
+		"OUTPUT_POLICY": &c.OutputPolicy,
+		"RECORD_DIR":    &c.RecordDir,
+		"CATALOG_DIR":   &c.CatalogDir,
+	}
+	for key, field := range strs {
+		if value, ok := os.LookupEnv(envPrefix + key); ok {
+			*field = value
+		}
+	}
+
+	ints := map[string]*int{
+		"REQUEST_TIMEOUT":    &c.RequestTimeout,
+		"DIAL_TIMEOUT":       &c.DialTimeout,
+		"HEARTBEAT_INTERVAL": &c.HeartbeatInterval,
+		"DISPATCH_WORKERS":   &c.DispatchWorkers,
+		"OUTPUT_BUFFER":      &c.OutputBuffer,
+		"DEDUP_SIZE":         &c.DedupSize,
+		"DEDUP_TTL":          &c.DedupTTL,
+		"SIGN_TIMEOUT":       &c.SignTimeout,
+		"SIGN_CACHE_TTL":     &c.SignCacheTTL,
+	}
+	if value, ok := os.LookupEnv(envPrefix + "SIGNERS"); ok {
+		c.Signers = strings.Split(value, ",")
+	}
+
+	for key, field := range ints {
+		value, ok := os.LookupEnv(envPrefix + key)
+		if !ok {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf(InvalidEnvError, envPrefix+key, value)
+		}
+		*field = n
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+package enums
+
+const (
+	LifecycleConnecting      = "connecting"
+	LifecycleConnected       = "connected"
+	LifecycleDisconnected    = "disconnected"
+	LifecycleReconnecting    = "reconnecting"
+	LifecycleReconnected     = "reconnected"
+	LifecycleReconnectFailed = "reconnect_failed"
+	LifecycleStopped         = "stopped"
+)
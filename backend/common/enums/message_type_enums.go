@@ -13,4 +13,11 @@ const (
 	WebcastRoomStatsMessage   = "WebcastRoomStatsMessage"
 	WebcastRoomMessage        = "WebcastRoomMessage"
 	WebcastRoomRankMessage    = "WebcastRoomRankMessage"
+
+	WebcastLiveShoppingMessage  = "WebcastLiveShoppingMessage"
+	WebcastProductChangeMessage = "WebcastProductChangeMessage"
+
+	WebcastMatchAgainstScoreMessage = "WebcastMatchAgainstScoreMessage"
+	WebcastUpdateFanTicketMessage   = "WebcastUpdateFanTicketMessage"
+	WebcastCommonTextMessage        = "WebcastCommonTextMessage"
 )
@@ -0,0 +1,222 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"sync"
+)
+
+// 输出缓冲策略，决定缓冲区满时如何处理新事件
+const (
+	PolicyBlock      = "block"       // 等待消费者取走，解码随之暂停
+	PolicyDropOldest = "drop-oldest" // 丢弃缓冲区中最早的事件
+	PolicyDropNewest = "drop-newest" // 丢弃新到的事件
+	PolicySample     = "sample"      // 缓冲过半时对低价值事件抽样，满时优先丢弃最早的低价值事件
+
+	DefaultBufferSize = 1024
+	DefaultSampleRate = 10
+)
+
+// SampledMethods 是 PolicySample 下会被抽样的低价值消息
+var SampledMethods = map[string]bool{
+	enums.PayloadTypeAck:            true,
+	enums.WebcastLikeMessage:        true,
+	enums.WebcastRoomUserSeqMessage: true,
+	enums.WebcastRoomStatsMessage:   true,
+
+	enums.WebcastUpdateFanTicketMessage: true,
+}
+
+// OutputStats 是输出缓冲的计数快照
+type OutputStats struct {
+	Policy          string
+	Capacity        int
+	Buffered        int
+	Delivered       uint64
+	Dropped         uint64
+	DroppedByMethod map[string]uint64
+	Duplicates      uint64 // 按 msgId 去重跳过的消息数
+}
+
+// buffer 位于分发流水线与 out 之间，保证慢速消费者不会阻塞解码与 ack
+type buffer struct {
+	policy     string
+	capacity   int
+	sampleRate uint64
+	wg         *inflight
+	done       <-chan struct{}
+
+	mu        sync.Mutex
+	closed    bool
+	queue     []Event
+	ready     chan struct{}
+	space     chan struct{}
+	sampled   uint64
+	delivered uint64
+	dropped   map[string]uint64
+}
+
+func newBuffer(opts Options, wg *inflight, done <-chan struct{}) *buffer {
+	b := &buffer{
+		policy:     opts.Policy,
+		capacity:   opts.BufferSize,
+		sampleRate: uint64(opts.SampleRate),
+		wg:         wg,
+		done:       done,
+		ready:      make(chan struct{}, 1),
+		space:      make(chan struct{}, 1),
+		dropped:    make(map[string]uint64),
+	}
+	if b.policy == "" {
+		b.policy = PolicySample
+	}
+	if b.capacity <= 0 {
+		b.capacity = DefaultBufferSize
+	}
+	if b.sampleRate == 0 {
+		b.sampleRate = DefaultSampleRate
+	}
+	return b
+}
+
+// push 按策略放入事件，只有 PolicyBlock 会阻塞
+func (b *buffer) push(event Event) {
+	method := event.Meta().Method
+	for {
+		b.mu.Lock()
+		if b.closed {
+			b.mu.Unlock()
+			return
+		}
+		if b.policy == PolicySample && SampledMethods[method] && len(b.queue) >= b.capacity/2 {
+			b.sampled++
+			if b.sampled%b.sampleRate != 0 {
+				b.drop(method)
+				b.mu.Unlock()
+				return
+			}
+		}
+		if len(b.queue) < b.capacity {
+			b.append(event)
+			b.mu.Unlock()
+			return
+		}
+
+		switch b.policy {
+		case PolicyDropOldest:
+			b.evict(0)
+			b.append(event)
+		case PolicySample:
+			if i := b.oldestSampled(); i >= 0 {
+				b.evict(i)
+				b.append(event)
+			} else {
+				b.drop(method)
+			}
+		case PolicyBlock:
+			b.mu.Unlock()
+			select {
+			case <-b.space:
+				continue
+			case <-b.done:
+				return
+			}
+		default:
+			b.drop(method)
+		}
+		b.mu.Unlock()
+		return
+	}
+}
+
+// run 将缓冲区中的事件依次写入 out，done 关闭后丢弃剩余事件并退出
+func (b *buffer) run(out chan<- Event) {
+	defer b.discard()
+	for {
+		b.mu.Lock()
+		if len(b.queue) == 0 {
+			b.mu.Unlock()
+			select {
+			case <-b.ready:
+				continue
+			case <-b.done:
+				return
+			}
+		}
+		event := b.queue[0]
+		b.queue[0] = nil
+		b.queue = b.queue[1:]
+		b.mu.Unlock()
+		signal(b.space)
+
+		select {
+		case out <- event:
+			b.mu.Lock()
+			b.delivered++
+			b.mu.Unlock()
+		case <-b.done:
+			b.wg.Done()
+			return
+		}
+		b.wg.Done()
+	}
+}
+
+// discard 在 done 关闭后清空缓冲区并拒绝新事件，释放 Wait
+func (b *buffer) discard() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.closed = true
+	for range b.queue {
+		b.wg.Done()
+	}
+	b.queue = nil
+}
+
+func (b *buffer) stats() OutputStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	stats := OutputStats{
+		Policy:          b.policy,
+		Capacity:        b.capacity,
+		Buffered:        len(b.queue),
+		Delivered:       b.delivered,
+		DroppedByMethod: make(map[string]uint64, len(b.dropped)),
+	}
+	for method, n := range b.dropped {
+		stats.Dropped += n
+		stats.DroppedByMethod[method] = n
+	}
+	return stats
+}
+
+func (b *buffer) append(event Event) {
+	b.wg.Add(1)
+	b.queue = append(b.queue, event)
+	signal(b.ready)
+}
+
+func (b *buffer) evict(i int) {
+	b.drop(b.queue[i].Meta().Method)
+	b.queue = append(b.queue[:i], b.queue[i+1:]...)
+	b.wg.Done()
+}
+
+func (b *buffer) drop(method string) {
+	b.dropped[method]++
+}
+
+func (b *buffer) oldestSampled() int {
+	for i, event := range b.queue {
+		if SampledMethods[event.Meta().Method] {
+			return i
+		}
+	}
+	return -1
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
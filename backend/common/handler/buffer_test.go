@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"testing"
+)
+
+func testEvent(method string, id uint64) Event {
+	return RoomEvent{EventMeta: EventMeta{Method: method, MsgId: id}}
+}
+
+func TestBufferPolicies(t *testing.T) {
+	cases := []struct {
+		policy  string
+		want    []uint64
+		dropped map[string]uint64
+	}{
+		{PolicyDropOldest, []uint64{3, 4}, map[string]uint64{enums.WebcastChatMessage: 1, enums.WebcastLikeMessage: 1}},
+		{PolicyDropNewest, []uint64{1, 2}, map[string]uint64{enums.WebcastChatMessage: 2}},
+		{PolicySample, []uint64{1, 3}, map[string]uint64{enums.WebcastLikeMessage: 1, enums.WebcastChatMessage: 1}},
+	}
+	for _, c := range cases {
+		var wg inflight
+		b := newBuffer(Options{Policy: c.policy, BufferSize: 2, SampleRate: 1}, &wg, make(chan struct{}))
+		b.push(testEvent(enums.WebcastChatMessage, 1))
+		b.push(testEvent(enums.WebcastLikeMessage, 2))
+		b.push(testEvent(enums.WebcastChatMessage, 3))
+		b.push(testEvent(enums.WebcastChatMessage, 4))
+
+		var got []uint64
+		for _, event := range b.queue {
+			got = append(got, event.Meta().MsgId)
+		}
+		if len(got) != len(c.want) || got[0] != c.want[0] || got[1] != c.want[1] {
+			t.Errorf("%s: buffered %v, want %v", c.policy, got, c.want)
+		}
+		stats := b.stats()
+		for method, n := range c.dropped {
+			if stats.DroppedByMethod[method] != n {
+				t.Errorf("%s: dropped %v, want %v", c.policy, stats.DroppedByMethod, c.dropped)
+			}
+		}
+		b.discard()
+		wg.Wait()
+	}
+}
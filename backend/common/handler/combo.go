@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 礼物连击合并
+//
+// 连击时每次连击都会收到一条 GiftMessage，comboCount 为累计的连击次数，最后一条带有 repeatEnd。
+// Handler 按 用户 + 礼物 + groupId（缺失时用 traceId）合并同一次连击，收到 repeatEnd、
+// comboTimeout 内没有新的连击或 Wait 时输出一条 GiftComboEvent。原始的 GiftEvent 照常输出，
+// 文本中只渲染合并后的结果。
+
+const (
+	GiftComboMethod = "gift-combo"
+
+	comboTimeout    = 10 * time.Second
+	comboCheckEvery = time.Second
+)
+
+// GiftComboEvent 是一次连击合并后的礼物，Method 为 GiftComboMethod。
+// Count 为礼物总数，Value 为 Count * DiamondCount 钻
+type GiftComboEvent struct {
+	EventMeta
+	User         *User
+	ToUser       *User
+	GiftId       uint64
+	GiftName     string
+	DiamondCount uint32 // 单个礼物的钻石数
+	Count        uint64
+	Value        uint64
+	Ticks        int // 合并的 GiftEvent 条数
+	GroupId      uint64
+	TraceId      string
+	StartedAt    time.Time
+	RepeatEnd    bool      // false 表示因超时结束
+	TrayText     *RichText // 最近一条连击的托盘文案
+}
+
+type combo struct {
+	event    GiftComboEvent
+	lastTick time.Time
+}
+
+// combos 只在输出协程中使用，无需加锁
+type combos struct {
+	pending map[string]*combo
+}
+
+func newCombos() *combos {
+	return &combos{pending: make(map[string]*combo)}
+}
+
+func comboKey(e GiftEvent) string {
+	var userId uint64
+	if e.User != nil {
+		userId = e.User.Id
+	}
+	key := strconv.FormatUint(userId, 10) + ":" + strconv.FormatUint(e.GiftId, 10) + ":"
+	switch {
+	case e.GroupId != 0:
+		return key + strconv.FormatUint(e.GroupId, 10)
+	case e.TraceId != "":
+		return key + e.TraceId
+	}
+	return key + "msg:" + strconv.FormatUint(e.MsgId, 10)
+}
+
+// giftCount 返回截至该条连击的礼物总数
+func giftCount(e GiftEvent) uint64 {
+	count := e.ComboCount
+	if count == 0 {
+		count = e.RepeatCount
+	}
+	if count == 0 {
+		count = 1
+	}
+	if e.GroupCount > 1 {
+		count *= e.GroupCount
+	}
+	return count
+}
+
+// observe 记录一条连击，收到 repeatEnd 时返回合并后的事件
+func (c *combos) observe(e GiftEvent, now time.Time) (GiftComboEvent, bool) {
+	key := comboKey(e)
+	current, ok := c.pending[key]
+	if !ok {
+		current = &combo{event: GiftComboEvent{
+			User:      e.User,
+			ToUser:    e.ToUser,
+			GiftId:    e.GiftId,
+			GroupId:   e.GroupId,
+			TraceId:   e.TraceId,
+			StartedAt: e.Time(),
+		}}
+		c.pending[key] = current
+	}
+	current.lastTick = now
+	event := &current.event
+	event.EventMeta = EventMeta{
+		Method:     GiftComboMethod,
+		RoomId:     e.RoomId,
+		CreateTime: e.CreateTime,
+		ReceivedAt: e.ReceivedAt,
+	}
+	if e.GiftName != "" {
+		event.GiftName = e.GiftName
+	}
+	if e.DiamondCount > 0 {
+		event.DiamondCount = e.DiamondCount
+	}
+	if e.TrayText != nil {
+		event.TrayText = e.TrayText
+	}
+	// 连击可能乱序或重复到达，取最大值
+	if count := giftCount(e); count > event.Count {
+		event.Count = count
+	}
+	event.Value = event.Count * uint64(event.DiamondCount)
+	event.Ticks++
+	if !e.RepeatEnd {
+		return GiftComboEvent{}, false
+	}
+	delete(c.pending, key)
+	event.RepeatEnd = true
+	return *event, true
+}
+
+// expire 返回超过 comboTimeout 没有新连击的合并结果，按开始时间排序
+func (c *combos) expire(now time.Time) []GiftComboEvent {
+	return c.take(func(current *combo) bool {
+		return now.Sub(current.lastTick) >= comboTimeout
+	})
+}
+
+// flush 返回所有尚未结束的连击，用于流结束时
+func (c *combos) flush() []GiftComboEvent {
+	return c.take(func(*combo) bool { return true })
+}
+
+func (c *combos) take(done func(*combo) bool) []GiftComboEvent {
+	var expired []GiftComboEvent
+	for key, current := range c.pending {
+		if !done(current) {
+			continue
+		}
+		delete(c.pending, key)
+		expired = append(expired, current.event)
+	}
+	sort.Slice(expired, func(i, j int) bool {
+		return expired[i].StartedAt.Before(expired[j].StartedAt)
+	})
+	return expired
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/log"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGiftCombo(t *testing.T) {
+	out := make(chan Event, 64)
+	h := NewHandler(log.NewRoomLogger("combo-test"), out, Options{Policy: PolicyBlock})
+	defer h.Close()
+
+	user := mockserver.NewUser(42, "viewer")
+	tick := func(groupId, combo uint64, repeatEnd uint32) *message.Message {
+		return mockserver.NewMessage(enums.WebcastGiftMessage, &message.GiftMessage{
+			Common:     mockserver.NewCommon(enums.WebcastGiftMessage),
+			User:       user,
+			GiftId:     1,
+			Gift:       &message.GiftStruct{Name: "小心心", DiamondCount: 10},
+			GroupId:    groupId,
+			GroupCount: 1,
+			ComboCount: combo,
+			RepeatEnd:  repeatEnd,
+		})
+	}
+	messages := []*message.Message{tick(7, 1, 0), tick(8, 1, 0), tick(7, 3, 0), tick(7, 2, 0), tick(7, 3, 1)}
+	frame, err := mockserver.EncodeFrame(1, mockserver.NewResponse(1, messages...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(discardConn{}, frame)
+	h.Wait()
+
+	var ticks int
+	var combos []GiftComboEvent
+	for len(out) > 0 {
+		switch e := (<-out).(type) {
+		case GiftEvent:
+			ticks++
+		case GiftComboEvent:
+			combos = append(combos, e)
+		}
+	}
+	if ticks != len(messages) {
+		t.Fatalf("ticks = %d, want %d", ticks, len(messages))
+	}
+	// group 8 没有 repeatEnd，在 Wait 时输出
+	if len(combos) != 2 || combos[1].GroupId != 8 || combos[1].RepeatEnd || combos[1].Value != 10 {
+		t.Fatalf("combos = %+v", combos)
+	}
+	combo := combos[0]
+	if combo.Method != GiftComboMethod || combo.GroupId != 7 || combo.Count != 3 || combo.Value != 30 || combo.Ticks != 4 || !combo.RepeatEnd {
+		t.Fatalf("combo = %+v", combo)
+	}
+	if text := FormatText(combo); !strings.Contains(text, "viewer 给  送出了 小心心 X 3，共 30 钻") {
+		t.Fatalf("FormatText = %q", text)
+	}
+
+	// 没有 repeatEnd 的连击在超时后输出
+	c := newCombos()
+	now := time.Now()
+	if _, done := c.observe(GiftEvent{User: &User{Id: 1}, GiftId: 1, GroupId: 8, ComboCount: 2, DiamondCount: 10}, now); done {
+		t.Fatal("combo finished without repeatEnd")
+	}
+	if expired := c.expire(now.Add(comboTimeout / 2)); len(expired) != 0 {
+		t.Fatalf("expired early = %+v", expired)
+	}
+	expired := c.expire(now.Add(comboTimeout))
+	if len(expired) != 1 || expired[0].RepeatEnd || expired[0].Value != 20 || len(c.pending) != 0 {
+		t.Fatalf("expired = %+v", expired)
+	}
+}
@@ -0,0 +1,291 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/message"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+)
+
+func decodeChatMessage(msg *message.Message) (Event, error) {
+	var chat message.ChatMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &chat); err != nil {
+		return nil, fmt.Errorf(ParseChatMessageError, err)
+	}
+	return ChatEvent{
+		EventMeta:  newMeta(msg, chat.GetCommon()),
+		User:       newUser(chat.GetUser()),
+		Content:    chat.GetContent(),
+		EventTime:  chat.GetEventTime(),
+		RtfContent: NewRichText(chat.GetRtfContent()),
+	}, nil
+}
+
+func decodeGiftMessage(msg *message.Message) (Event, error) {
+	var gift message.GiftMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &gift); err != nil {
+		return nil, fmt.Errorf(ParseGiftMessageError, err)
+	}
+	return GiftEvent{
+		EventMeta:    newMeta(msg, gift.GetCommon()),
+		User:         newUser(gift.GetUser()),
+		ToUser:       newUser(gift.GetToUser()),
+		GiftId:       gift.GetGiftId(),
+		GiftName:     gift.GetGift().GetName(),
+		DiamondCount: gift.GetGift().GetDiamondCount(),
+		ComboCount:   gift.GetComboCount(),
+		RepeatCount:  gift.GetRepeatCount(),
+		GroupCount:   gift.GetGroupCount(),
+		GroupId:      gift.GetGroupId(),
+		RepeatEnd:    gift.GetRepeatEnd() == 1,
+		TraceId:      gift.GetTraceId(),
+		TrayText:     NewRichText(gift.GetTrayDisplayText()),
+	}, nil
+}
+
+func decodeMemberMessage(msg *message.Message) (Event, error) {
+	var member message.MemberMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &member); err != nil {
+		return nil, fmt.Errorf(ParseMemberMessageError, err)
+	}
+	return MemberEvent{
+		EventMeta:   newMeta(msg, member.GetCommon()),
+		User:        newUser(member.GetUser()),
+		MemberCount: member.GetMemberCount(),
+		Action:      member.GetAction(),
+		AnchorText:  NewRichText(member.GetAnchorDisplayText()),
+	}, nil
+}
+
+func decodeLikeMessage(msg *message.Message) (Event, error) {
+	var like message.LikeMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &like); err != nil {
+		return nil, fmt.Errorf(ParseLikeMessageError, err)
+	}
+	return LikeEvent{
+		EventMeta: newMeta(msg, like.GetCommon()),
+		User:      newUser(like.GetUser()),
+		Count:     like.GetCount(),
+		Total:     like.GetTotal(),
+	}, nil
+}
+
+func decodeSocialMessage(msg *message.Message) (Event, error) {
+	var social message.SocialMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &social); err != nil {
+		return nil, fmt.Errorf(ParseSocialMessageError, err)
+	}
+	return SocialEvent{
+		EventMeta:   newMeta(msg, social.GetCommon()),
+		User:        newUser(social.GetUser()),
+		Action:      social.GetAction(),
+		ShareType:   social.GetShareType(),
+		FollowCount: social.GetFollowCount(),
+	}, nil
+}
+
+func decodeRoomUserSeqMessage(msg *message.Message) (Event, error) {
+	var roomUserSeq message.RoomUserSeqMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &roomUserSeq); err != nil {
+		return nil, fmt.Errorf(ParseRoomUserSeqMessageError, err)
+	}
+	return RoomUserSeqEvent{
+		EventMeta:  newMeta(msg, roomUserSeq.GetCommon()),
+		Current:    roomUserSeq.GetTotal(),
+		TotalPv:    roomUserSeq.GetTotalPvForAnchor(),
+		TotalUser:  roomUserSeq.GetTotalUser(),
+		Popularity: roomUserSeq.GetPopularity(),
+	}, nil
+}
+
+func decodeFansclubMessage(msg *message.Message) (Event, error) {
+	var fansclub message.FansclubMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &fansclub); err != nil {
+		return nil, fmt.Errorf(ParseFansclubMessageError, err)
+	}
+	return FansclubEvent{
+		EventMeta: newMeta(msg, fansclub.GetCommonInfo()),
+		User:      newUser(fansclub.GetUser()),
+		Type:      fansclub.GetType(),
+		Content:   fansclub.GetContent(),
+	}, nil
+}
+
+func decodeControlMessage(msg *message.Message) (Event, error) {
+	var control message.ControlMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &control); err != nil {
+		return nil, fmt.Errorf(ParseControlMessageError, err)
+	}
+	return ControlEvent{
+		EventMeta: newMeta(msg, control.GetCommon()),
+		Status:    control.GetStatus(),
+	}, nil
+}
+
+func decodeEmojiChatMessage(msg *message.Message) (Event, error) {
+	var emoji message.EmojiChatMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &emoji); err != nil {
+		return nil, fmt.Errorf(ParseEmojiChatMessageError, err)
+	}
+	return EmojiChatEvent{
+		EventMeta:      newMeta(msg, emoji.GetCommon()),
+		User:           newUser(emoji.GetUser()),
+		EmojiId:        emoji.GetEmojiId(),
+		DefaultContent: emoji.GetDefaultContent(),
+	}, nil
+}
+
+func decodeRoomStatsMessage(msg *message.Message) (Event, error) {
+	var roomStats message.RoomStatsMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &roomStats); err != nil {
+		return nil, fmt.Errorf(ParseRoomStatsMessageError, err)
+	}
+	return RoomStatsEvent{
+		EventMeta:     newMeta(msg, roomStats.GetCommon()),
+		DisplayShort:  roomStats.GetDisplayShort(),
+		DisplayMiddle: roomStats.GetDisplayMiddle(),
+		DisplayLong:   roomStats.GetDisplayLong(),
+		DisplayValue:  roomStats.GetDisplayValue(),
+		Total:         roomStats.GetTotal(),
+	}, nil
+}
+
+func decodeRoomMessage(msg *message.Message) (Event, error) {
+	var room message.RoomMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &room); err != nil {
+		return nil, fmt.Errorf(ParseRoomMessageError, err)
+	}
+	return RoomEvent{
+		EventMeta: newMeta(msg, room.GetCommon()),
+	}, nil
+}
+
+func decodeLiveShoppingMessage(msg *message.Message) (Event, error) {
+	var shopping message.LiveShoppingMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &shopping); err != nil {
+		return nil, fmt.Errorf(ParseLiveShoppingError, err)
+	}
+	return LiveShoppingEvent{
+		EventMeta:   newMeta(msg, shopping.GetCommon()),
+		MsgType:     shopping.GetMsgType(),
+		PromotionId: shopping.GetPromotionId(),
+	}, nil
+}
+
+func decodeProductChangeMessage(msg *message.Message) (Event, error) {
+	var change message.ProductChangeMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &change); err != nil {
+		return nil, fmt.Errorf(ParseProductChangeError, err)
+	}
+	products := make([]ProductInfo, 0, len(change.GetUpdateProductInfoList()))
+	for _, product := range change.GetUpdateProductInfoList() {
+		products = append(products, ProductInfo{
+			PromotionId:     product.GetPromotionId(),
+			Index:           product.GetIndex(),
+			ExplainType:     product.GetExplainType(),
+			TargetFlashUids: product.GetTargetFlashUidsList(),
+		})
+	}
+	categories := make([]CategoryInfo, 0, len(change.GetUpdateCategoryInfoList()))
+	for _, category := range change.GetUpdateCategoryInfoList() {
+		categories = append(categories, CategoryInfo{
+			Id:           category.GetId(),
+			Name:         category.GetName(),
+			Type:         category.GetType(),
+			UniqueIndex:  category.GetUniqueIndex(),
+			PromotionIds: category.GetPromotionIdsList(),
+		})
+	}
+	return ProductChangeEvent{
+		EventMeta:       newMeta(msg, change.GetCommon()),
+		UpdateTimestamp: change.GetUpdateTimestamp(),
+		Toast:           change.GetUpdateToast(),
+		Total:           change.GetTotal(),
+		Products:        products,
+		Categories:      categories,
+	}, nil
+}
+
+func decodeMatchAgainstScoreMessage(msg *message.Message) (Event, error) {
+	var score message.MatchAgainstScoreMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &score); err != nil {
+		return nil, fmt.Errorf(ParseMatchAgainstScoreError, err)
+	}
+	against := score.GetAgainst()
+	return MatchScoreEvent{
+		EventMeta: newMeta(msg, score.GetCommon()),
+		Left: Team{
+			Id:            against.GetLeftTeamId(),
+			Name:          against.GetLeftName(),
+			Logo:          imageUrl(against.GetLeftLogo()),
+			Goal:          against.GetLeftGoal(),
+			Score:         against.GetLeftGoalInt(),
+			ScoreAddition: against.GetLeftScoreAddition(),
+		},
+		Right: Team{
+			Id:            against.GetRightTeamId(),
+			Name:          against.GetRightName(),
+			Logo:          imageUrl(against.GetRightLogo()),
+			Goal:          against.GetRightGoal(),
+			Score:         against.GetRightGoalInt(),
+			ScoreAddition: against.GetRightScoreAddition(),
+		},
+		Stage:         against.GetCurrentGoalStage(),
+		FinalStage:    against.GetFinalGoalStage(),
+		MatchStatus:   score.GetMatchStatus(),
+		DisplayStatus: score.GetDisplayStatus(),
+		Version:       against.GetVersion(),
+		Timestamp:     against.GetTimestamp(),
+	}, nil
+}
+
+func decodeUpdateFanTicketMessage(msg *message.Message) (Event, error) {
+	var fanTicket message.UpdateFanTicketMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &fanTicket); err != nil {
+		return nil, fmt.Errorf(ParseUpdateFanTicketError, err)
+	}
+	return FanTicketEvent{
+		EventMeta:   newMeta(msg, fanTicket.GetCommon()),
+		Count:       fanTicket.GetRoomFanTicketCount(),
+		CountText:   fanTicket.GetRoomFanTicketCountText(),
+		ForceUpdate: fanTicket.GetForceUpdate(),
+	}, nil
+}
+
+func decodeCommonTextMessage(msg *message.Message) (Event, error) {
+	var text message.CommonTextMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &text); err != nil {
+		return nil, fmt.Errorf(ParseCommonTextError, err)
+	}
+	return CommonTextEvent{
+		EventMeta: newMeta(msg, text.GetCommon()),
+		User:      newUser(text.GetUser()),
+		Scene:     text.GetScene(),
+		Text:      text.GetCommon().GetDescribe(),
+	}, nil
+}
+
+func decodeRoomRankMessage(msg *message.Message) (Event, error) {
+	var roomRank message.RoomRankMessage
+	if err := proto.Unmarshal(msg.GetPayload(), &roomRank); err != nil {
+		return nil, fmt.Errorf(ParseRoomRankMessageError, err)
+	}
+	ranks := make([]RankItem, 0, len(roomRank.GetRanksList()))
+	for _, rank := range roomRank.GetRanksList() {
+		ranks = append(ranks, RankItem{
+			User:  newUser(rank.GetUser()),
+			Score: rank.GetScoreStr(),
+		})
+	}
+	return RoomRankEvent{
+		EventMeta: newMeta(msg, roomRank.GetCommon()),
+		Ranks:     ranks,
+	}, nil
+}
+
+// imageUrl 返回图片的第一个地址
+func imageUrl(image *message.Image) string {
+	if urls := image.GetUrlListList(); len(urls) > 0 {
+		return urls[0]
+	}
+	return ""
+}
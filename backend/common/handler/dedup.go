@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"time"
+)
+
+const (
+	DefaultDedupSize = 10000
+	DefaultDedupTTL  = 10 * time.Minute
+)
+
+// dedup 记录最近输出过的 Common.msgId。重连后服务端会补发最近的消息（need_persist_msg_count），
+// Handler 在整个采集会话内复用同一个 dedup，因此补发的消息不会被重复输出。
+// 窗口同时受数量与时间限制：超过 size 条时淘汰最早的记录，超过 ttl 的记录视为过期
+type dedup struct {
+	size  int
+	ttl   time.Duration
+	seen  map[uint64]time.Time
+	order []uint64
+}
+
+func newDedup(size int, ttl time.Duration) *dedup {
+	if size == 0 {
+		size = DefaultDedupSize
+	}
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	return &dedup{
+		size: size,
+		ttl:  ttl,
+		seen: make(map[uint64]time.Time),
+	}
+}
+
+// duplicate 判断 msgId 是否已在窗口内出现过，未出现时记录下来。msgId 为 0 或 size < 0 时不去重
+func (d *dedup) duplicate(msgId uint64, now time.Time) bool {
+	if msgId == 0 || d.size < 0 {
+		return false
+	}
+	d.expire(now)
+	if _, ok := d.seen[msgId]; ok {
+		return true
+	}
+	if len(d.order) >= d.size {
+		delete(d.seen, d.order[0])
+		d.order = d.order[1:]
+	}
+	d.seen[msgId] = now
+	d.order = append(d.order, msgId)
+	return false
+}
+
+func (d *dedup) expire(now time.Time) {
+	for len(d.order) > 0 {
+		id := d.order[0]
+		if now.Sub(d.seen[id]) < d.ttl {
+			return
+		}
+		delete(d.seen, id)
+		d.order = d.order[1:]
+	}
+}
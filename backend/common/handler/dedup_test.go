@@ -0,0 +1,26 @@
+package handler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDedupWindow(t *testing.T) {
+	now := time.Now()
+	d := newDedup(2, time.Minute)
+
+	if d.duplicate(1, now) || !d.duplicate(1, now) {
+		t.Fatal("msgId 1 should be a duplicate the second time")
+	}
+	if d.duplicate(0, now) || d.duplicate(0, now) {
+		t.Fatal("msgId 0 must never be deduplicated")
+	}
+	d.duplicate(2, now)
+	d.duplicate(3, now)
+	if d.duplicate(1, now) {
+		t.Fatal("msgId 1 should have been evicted by size")
+	}
+	if d.duplicate(3, now.Add(2*time.Minute)) {
+		t.Fatal("msgId 3 should have expired")
+	}
+}
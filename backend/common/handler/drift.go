@@ -0,0 +1,289 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/message"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// 协议变化检测
+//
+// 抖音会在不通知的情况下修改消息结构。proto.Unmarshal 会保留 douyin.proto 中没有定义的字段，
+// 对注册了 schema 的方法，Handler 在解码后检查这些未知字段，并统计每个方法的解码失败率。
+// 新出现的未知字段或失败率超过 DriftErrorRate 时输出一条 DriftEvent，完整统计见 DriftReport。
+
+const (
+	DriftMethod = "drift"
+
+	DriftUnknownField = "unknown-field" // 出现了 douyin.proto 中未定义的字段
+	DriftDecodeErrors = "decode-errors" // 解码失败率超过 DriftErrorRate
+
+	DriftErrorRate  = 0.05
+	driftMinSamples = 50   // 样本数达到后才计算失败率
+	driftCheckAll   = 1000 // 每个方法前 driftCheckAll 条消息全部检查，之后抽样
+	driftCheckEvery = 10
+)
+
+// DriftEvent 是协议变化告警，Method 为 DriftMethod，Source 为受影响的消息方法
+type DriftEvent struct {
+	EventMeta
+	Kind      string
+	Source    string
+	Field     string // 未知字段路径，如 "user.15"，Kind 为 DriftUnknownField 时有效
+	WireType  string
+	Errors    uint64
+	Total     uint64
+	ErrorRate float64
+}
+
+// DriftReport 是一个 Handler 的协议变化统计
+type DriftReport struct {
+	Methods        []MethodDrift     // 按方法名排序
+	UnknownMethods map[string]uint64 // 没有注册解码器的方法及其消息数
+}
+
+// MethodDrift 是单个方法的统计。未知字段在抽样检查的消息中统计
+type MethodDrift struct {
+	Method        string
+	Type          string // douyin.proto 中的消息名
+	Decoded       uint64
+	Checked       uint64
+	Errors        uint64
+	ErrorRate     float64
+	UnknownFields []UnknownField
+}
+
+// UnknownField 是一个未在 douyin.proto 中定义的字段，Path 中已知字段用字段名，未知字段用编号
+type UnknownField struct {
+	Path      string
+	WireType  string
+	Count     uint64
+	FirstSeen time.Time
+}
+
+// Drifted 判断是否出现过未知字段或失败率超过阈值
+func (r DriftReport) Drifted() bool {
+	for _, m := range r.Methods {
+		if len(m.UnknownFields) > 0 || m.ErrorRate >= DriftErrorRate && m.Decoded+m.Errors >= driftMinSamples {
+			return true
+		}
+	}
+	return false
+}
+
+type drift struct {
+	mu      sync.Mutex
+	methods map[string]*methodDrift
+	unknown map[string]uint64
+	warned  map[string]bool // 已告警的 "消息名.字段编号"，同一个嵌套消息在不同方法中只告警一次
+	pending []Event
+}
+
+type methodDrift struct {
+	typ     string
+	decoded uint64
+	checked uint64
+	errors  uint64
+	warned  bool
+	fields  map[string]*UnknownField
+}
+
+func newDrift() *drift {
+	return &drift{
+		methods: make(map[string]*methodDrift),
+		unknown: make(map[string]uint64),
+		warned:  make(map[string]bool),
+	}
+}
+
+func (d *drift) method(method string) *methodDrift {
+	m, ok := d.methods[method]
+	if !ok {
+		m = &methodDrift{fields: make(map[string]*UnknownField)}
+		d.methods[method] = m
+	}
+	return m
+}
+
+// check 在 worker 中执行，按 schema 重新解析 payload 并记录未知字段
+func (d *drift) check(msg *message.Message, schema protoreflect.MessageType) {
+	method := msg.GetMethod()
+	d.mu.Lock()
+	m := d.method(method)
+	m.typ = string(schema.Descriptor().Name())
+	m.decoded++
+	d.checkRate(method, m)
+	sampled := m.decoded <= driftCheckAll || m.decoded%driftCheckEvery == 0
+	if sampled {
+		m.checked++
+	}
+	d.mu.Unlock()
+	if !sampled {
+		return
+	}
+
+	target := schema.New().Interface()
+	if err := proto.Unmarshal(msg.GetPayload(), target); err != nil {
+		return
+	}
+	now := time.Now()
+	visitUnknown(target.ProtoReflect(), "", func(path, key string, typ protowire.Type) {
+		d.mu.Lock()
+		defer d.mu.Unlock()
+		field, ok := m.fields[path]
+		if !ok {
+			field = &UnknownField{Path: path, WireType: wireTypeName(typ), FirstSeen: now}
+			m.fields[path] = field
+		}
+		field.Count++
+		if !d.warned[key] {
+			d.warned[key] = true
+			d.pending = append(d.pending, DriftEvent{
+				EventMeta: EventMeta{Method: DriftMethod, ReceivedAt: now},
+				Kind:      DriftUnknownField,
+				Source:    method,
+				Field:     path,
+				WireType:  field.WireType,
+			})
+		}
+	})
+}
+
+// failed 记录一次解码失败
+func (d *drift) failed(method string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	m := d.method(method)
+	m.errors++
+	d.checkRate(method, m)
+}
+
+// checkRate 在失败率首次超过阈值时告警，调用方需持有锁
+func (d *drift) checkRate(method string, m *methodDrift) {
+	total := m.decoded + m.errors
+	rate := float64(m.errors) / float64(total)
+	if m.warned || total < driftMinSamples || rate < DriftErrorRate {
+		return
+	}
+	m.warned = true
+	d.pending = append(d.pending, DriftEvent{
+		EventMeta: EventMeta{Method: DriftMethod, ReceivedAt: time.Now()},
+		Kind:      DriftDecodeErrors,
+		Source:    method,
+		Errors:    m.errors,
+		Total:     total,
+		ErrorRate: rate,
+	})
+}
+
+func (d *drift) unknownMethod(method string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.unknown[method]++
+}
+
+// take 取出待输出的告警
+func (d *drift) take() []Event {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	pending := d.pending
+	d.pending = nil
+	return pending
+}
+
+func (d *drift) report() DriftReport {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	report := DriftReport{UnknownMethods: make(map[string]uint64, len(d.unknown))}
+	for method, count := range d.unknown {
+		report.UnknownMethods[method] = count
+	}
+	for method, m := range d.methods {
+		item := MethodDrift{
+			Method:  method,
+			Type:    m.typ,
+			Decoded: m.decoded,
+			Checked: m.checked,
+			Errors:  m.errors,
+		}
+		if total := m.decoded + m.errors; total > 0 {
+			item.ErrorRate = float64(m.errors) / float64(total)
+		}
+		for _, field := range m.fields {
+			item.UnknownFields = append(item.UnknownFields, *field)
+		}
+		sort.Slice(item.UnknownFields, func(i, j int) bool {
+			return item.UnknownFields[i].Path < item.UnknownFields[j].Path
+		})
+		report.Methods = append(report.Methods, item)
+	}
+	sort.Slice(report.Methods, func(i, j int) bool {
+		return report.Methods[i].Method < report.Methods[j].Method
+	})
+	return report
+}
+
+// visitUnknown 递归遍历消息及其嵌套消息中的未知字段，key 为 "消息名.字段编号"
+func visitUnknown(m protoreflect.Message, prefix string, visit func(path, key string, typ protowire.Type)) {
+	raw := m.GetUnknown()
+	for len(raw) > 0 {
+		number, typ, n := protowire.ConsumeTag(raw)
+		if n < 0 {
+			return
+		}
+		raw = raw[n:]
+		if n = protowire.ConsumeFieldValue(number, typ, raw); n < 0 {
+			return
+		}
+		raw = raw[n:]
+		num := strconv.Itoa(int(number))
+		visit(joinPath(prefix, num), fmt.Sprintf("%s.%s", m.Descriptor().FullName(), num), typ)
+	}
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		path := joinPath(prefix, string(fd.Name()))
+		switch {
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				v.Map().Range(func(_ protoreflect.MapKey, value protoreflect.Value) bool {
+					visitUnknown(value.Message(), path, visit)
+					return true
+				})
+			}
+		case fd.Message() == nil:
+		case fd.IsList():
+			for i := 0; i < v.List().Len(); i++ {
+				visitUnknown(v.List().Get(i).Message(), path, visit)
+			}
+		default:
+			visitUnknown(v.Message(), path, visit)
+		}
+		return true
+	})
+}
+
+func joinPath(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func wireTypeName(typ protowire.Type) string {
+	switch typ {
+	case protowire.VarintType:
+		return "varint"
+	case protowire.Fixed32Type:
+		return "fixed32"
+	case protowire.Fixed64Type:
+		return "fixed64"
+	case protowire.BytesType:
+		return "bytes"
+	}
+	return strconv.Itoa(int(typ))
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/log"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"google.golang.org/protobuf/encoding/protowire"
+	"testing"
+)
+
+func TestDriftUnknownFieldsAndErrorRate(t *testing.T) {
+	out := make(chan Event, 256)
+	h := NewHandler(log.NewRoomLogger("drift-test"), out, Options{Policy: PolicyBlock})
+	defer h.Close()
+
+	user := mockserver.NewUser(1, "viewer")
+	var messages []*message.Message
+	for i := 0; i < 3; i++ {
+		chat := mockserver.Chat(user, "hi")
+		chat.Payload = protowire.AppendString(protowire.AppendTag(chat.Payload, 99, protowire.BytesType), "new")
+		messages = append(messages, chat)
+	}
+	for i := 0; i < driftMinSamples; i++ {
+		like := mockserver.Like(user, 1)
+		if i%5 == 0 {
+			like.Payload = []byte{0xff}
+		}
+		messages = append(messages, like)
+	}
+	messages = append(messages, &message.Message{Method: "WebcastNewMessage", Payload: []byte{0x08, 0x01}})
+	frame, err := mockserver.EncodeFrame(1, mockserver.NewResponse(1, messages...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.Handle(discardConn{}, frame)
+	h.Wait()
+
+	// 多个 worker 并发解码，告警顺序不固定
+	warnings := make(map[string]DriftEvent)
+	for len(out) > 0 {
+		if e, ok := (<-out).(DriftEvent); ok {
+			warnings[e.Kind] = e
+		}
+	}
+	if len(warnings) != 2 {
+		t.Fatalf("warnings = %+v", warnings)
+	}
+	if w := warnings[DriftUnknownField]; w.Source != enums.WebcastChatMessage || w.Field != "99" || w.WireType != "bytes" {
+		t.Fatalf("unknown field warning = %+v", w)
+	}
+	if w := warnings[DriftDecodeErrors]; w.Source != enums.WebcastLikeMessage || w.ErrorRate < DriftErrorRate {
+		t.Fatalf("error rate warning = %+v", w)
+	}
+
+	report := h.DriftReport()
+	if !report.Drifted() || report.UnknownMethods["WebcastNewMessage"] != 1 {
+		t.Fatalf("report = %+v", report)
+	}
+	for _, m := range report.Methods {
+		if m.Method == enums.WebcastChatMessage && (len(m.UnknownFields) != 1 || m.UnknownFields[0].Count != 3) {
+			t.Fatalf("chat drift = %+v", m)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/message"
+	"time"
+)
+
+// Event 是解析后的结构化消息，具体类型见下方的 XxxEvent
+type Event interface {
+	Meta() EventMeta
+}
+
+// EventMeta 是所有事件共有的字段，取自 Message 与 Common
+type EventMeta struct {
+	Method     string
+	MsgId      uint64
+	RoomId     uint64
+	CreateTime uint64    // 服务端时间戳，毫秒，可能为 0
+	ReceivedAt time.Time // 本地收到该消息的时间
+}
+
+func (m EventMeta) Meta() EventMeta {
+	return m
+}
+
+// Time 优先使用服务端时间，缺失时使用本地收到的时间
+func (m EventMeta) Time() time.Time {
+	if m.CreateTime > 0 {
+		return time.UnixMilli(int64(m.CreateTime))
+	}
+	return m.ReceivedAt
+}
+
+// User 是消息中用户信息的精简版本
+type User struct {
+	Id            uint64
+	IdStr         string
+	SecUid        string
+	DisplayId     string
+	NickName      string
+	Gender        uint32 // 0 女，1 男，其余未知
+	PayLevel      int64
+	FansClubName  string
+	FansClubLevel int32
+}
+
+// AckEvent 表示已回复服务端 ack，Method 为 enums.PayloadTypeAck
+type AckEvent struct {
+	EventMeta
+	LogId uint64
+}
+
+// ChatEvent 聊天消息
+type ChatEvent struct {
+	EventMeta
+	User       *User
+	Content    string
+	EventTime  uint64    // 秒
+	RtfContent *RichText // 带样式的聊天内容
+}
+
+// EmojiChatEvent 表情消息
+type EmojiChatEvent struct {
+	EventMeta
+	User           *User
+	EmojiId        int64
+	DefaultContent string
+}
+
+// GiftEvent 礼物消息，连击时每次连击都会收到一条
+type GiftEvent struct {
+	EventMeta
+	User         *User
+	ToUser       *User
+	GiftId       uint64
+	GiftName     string
+	DiamondCount uint32
+	ComboCount   uint64
+	RepeatCount  uint64
+	GroupCount   uint64
+	GroupId      uint64
+	RepeatEnd    bool
+	TraceId      string
+	TrayText     *RichText // 礼物托盘上展示的文案
+}
+
+// MemberEvent 进场消息
+type MemberEvent struct {
+	EventMeta
+	User        *User
+	MemberCount uint64
+	Action      uint64
+	AnchorText  *RichText // 主播端展示的进场文案
+}
+
+// LikeEvent 点赞消息
+type LikeEvent struct {
+	EventMeta
+	User  *User
+	Count uint64
+	Total uint64
+}
+
+// SocialEvent 关注 / 分享消息
+type SocialEvent struct {
+	EventMeta
+	User        *User
+	Action      uint64
+	ShareType   uint64
+	FollowCount uint64
+}
+
+// RoomUserSeqEvent 在线人数统计
+type RoomUserSeqEvent struct {
+	EventMeta
+	Current    int64
+	TotalPv    string
+	TotalUser  int64
+	Popularity int64
+}
+
+// FansclubEvent 粉丝团消息
+type FansclubEvent struct {
+	EventMeta
+	User    *User
+	Type    int32 // 1 升级，2 加入
+	Content string
+}
+
+// ControlEvent 直播间控制消息，Status 为 3 表示下播
+type ControlEvent struct {
+	EventMeta
+	Status int32
+}
+
+func (e ControlEvent) LiveEnded() bool {
+	return e.Status == 3
+}
+
+// RoomStatsEvent 直播间统计消息
+type RoomStatsEvent struct {
+	EventMeta
+	DisplayShort  string
+	DisplayMiddle string
+	DisplayLong   string
+	DisplayValue  int64
+	Total         int64
+}
+
+// RoomEvent 直播间消息
+type RoomEvent struct {
+	EventMeta
+}
+
+// RankItem 排行榜中的一位用户
+type RankItem struct {
+	User  *User
+	Score string
+}
+
+// RoomRankEvent 直播间排行榜
+type RoomRankEvent struct {
+	EventMeta
+	Ranks []RankItem
+}
+
+// LiveShoppingEvent 直播间购物车消息，PromotionId 为当前讲解的商品，为 0 时表示结束讲解
+type LiveShoppingEvent struct {
+	EventMeta
+	MsgType     int32
+	PromotionId int64
+}
+
+// ProductInfo 是购物车中的一个商品，ExplainType 非 0 表示正在讲解
+type ProductInfo struct {
+	PromotionId     int64
+	Index           int32
+	ExplainType     int64
+	TargetFlashUids []int64
+}
+
+// CategoryInfo 是购物车中的一个商品分类
+type CategoryInfo struct {
+	Id           int32
+	Name         string
+	Type         string
+	UniqueIndex  string
+	PromotionIds []int64
+}
+
+// ProductChangeEvent 购物车商品或分类变化
+type ProductChangeEvent struct {
+	EventMeta
+	UpdateTimestamp int64
+	Toast           string
+	Total           int64
+	Products        []ProductInfo
+	Categories      []CategoryInfo
+}
+
+// Explaining 返回变化中正在讲解的商品，没有时返回 0
+func (e ProductChangeEvent) Explaining() int64 {
+	for _, product := range e.Products {
+		if product.ExplainType != 0 {
+			return product.PromotionId
+		}
+	}
+	return 0
+}
+
+// Team 是比分消息中的一方
+type Team struct {
+	Id            uint64
+	Name          string
+	Logo          string
+	Goal          string // 展示用的比分文本
+	Score         uint64
+	ScoreAddition uint32
+}
+
+// MatchScoreEvent 体育赛事比分更新，Version 递增，较旧的版本可以忽略
+type MatchScoreEvent struct {
+	EventMeta
+	Left          Team
+	Right         Team
+	Stage         uint32 // 当前阶段，如上下半场
+	FinalStage    uint32
+	MatchStatus   uint32
+	DisplayStatus uint32
+	Version       uint64
+	Timestamp     uint64
+}
+
+// FanTicketEvent 直播间音浪（主播收入的近似值）更新，Count 为本场累计值
+type FanTicketEvent struct {
+	EventMeta
+	Count       uint64
+	CountText   string
+	ForceUpdate bool
+}
+
+// CommonTextEvent 通用文本消息，多为系统提示，Scene 标识提示的场景，Text 取自 Common.describe
+type CommonTextEvent struct {
+	EventMeta
+	User  *User
+	Scene string
+	Text  string
+}
+
+func newMeta(msg *message.Message, common *message.Common) EventMeta {
+	meta := EventMeta{
+		Method:     msg.GetMethod(),
+		MsgId:      common.GetMsgId(),
+		RoomId:     common.GetRoomId(),
+		CreateTime: common.GetCreateTime(),
+		ReceivedAt: time.Now(),
+	}
+	if meta.MsgId == 0 && msg.GetMsgId() > 0 {
+		meta.MsgId = uint64(msg.GetMsgId())
+	}
+	return meta
+}
+
+func newUser(user *message.User) *User {
+	if user == nil {
+		return nil
+	}
+	return &User{
+		Id:            user.GetId(),
+		IdStr:         user.GetIdStr(),
+		SecUid:        user.GetSecUid(),
+		DisplayId:     user.GetDisplayId(),
+		NickName:      user.GetNickName(),
+		Gender:        user.GetGender(),
+		PayLevel:      user.GetPayGrade().GetLevel(),
+		FansClubName:  user.GetFansClub().GetData().GetClubName(),
+		FansClubLevel: user.GetFansClub().GetData().GetLevel(),
+	}
+}
+
+func newAckEvent(logId uint64) AckEvent {
+	return AckEvent{
+		EventMeta: EventMeta{Method: enums.PayloadTypeAck, ReceivedAt: time.Now()},
+		LogId:     logId,
+	}
+}
@@ -0,0 +1,30 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"testing"
+)
+
+func TestDecodeTypedEvents(t *testing.T) {
+	user := mockserver.NewUser(42, "viewer")
+	msg := mockserver.Gift(user, "小心心", 3)
+
+	event, _, err := NewRegistry().Decode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	gift, ok := event.(GiftEvent)
+	if !ok {
+		t.Fatalf("event = %T, want GiftEvent", event)
+	}
+	if gift.Method != enums.WebcastGiftMessage || gift.RoomId != 7400000000000000001 || gift.MsgId == 0 {
+		t.Fatalf("meta = %+v", gift.EventMeta)
+	}
+	if gift.User.Id != 42 || gift.GiftName != "小心心" || gift.ComboCount != 3 {
+		t.Fatalf("gift = %+v", gift)
+	}
+	if text := FormatText(gift); text != "" {
+		t.Fatalf("FormatText = %q, gift ticks are rendered as combos", text)
+	}
+}
@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"fmt"
+)
+
+// Formatter 将事件渲染为文本，返回空字符串表示不输出该事件
+type Formatter interface {
+	Format(event Event) string
+}
+
+// FormatterFunc 让普通函数实现 Formatter
+type FormatterFunc func(event Event) string
+
+func (f FormatterFunc) Format(event Event) string {
+	return f(event)
+}
+
+var (
+	// TextFormatter 输出 GUI 中使用的中文单行文本
+	TextFormatter Formatter = FormatterFunc(FormatText)
+	// HTMLFormatter 输出与 TextFormatter 相同的内容，富文本保留颜色、粗体与表情图片
+	HTMLFormatter Formatter = FormatterFunc(FormatHTML)
+	// MarkdownFormatter 输出与 TextFormatter 相同的内容，富文本保留粗体、斜体与图片
+	MarkdownFormatter Formatter = FormatterFunc(FormatMarkdown)
+)
+
+// FormatText 按消息类型渲染中文单行文本，未知类型输出方法名
+func FormatText(event Event) string {
+	return formatEvent(event, plainStyle{})
+}
+
+// FormatHTML 渲染为单行 HTML 片段，富文本以外的文字均已转义
+func FormatHTML(event Event) string {
+	return formatEvent(event, htmlStyle{})
+}
+
+// FormatMarkdown 渲染为单行 Markdown，富文本以外的文字均已转义
+func FormatMarkdown(event Event) string {
+	return formatEvent(event, markdownStyle{})
+}
+
+// formatEvent 按 style 渲染消息中的富文本，其余部分按纯文本渲染后交给 style 转义
+func formatEvent(event Event, style textStyle) string {
+	currentTime := event.Meta().Time().Format(enums.TimeFormat)
+	switch e := event.(type) {
+	case ChatEvent:
+		if e.Content == "" && e.RtfContent != nil {
+			return style.text(fmt.Sprintf("%s 【聊天消息】[ %v ] %v : ", currentTime, userId(e.User), nickName(e.User)), nil) +
+				e.RtfContent.render(style)
+		}
+	case GiftComboEvent:
+		if e.TrayText.Plain() != "" {
+			return style.text(fmt.Sprintf("%s 【礼物消息】%v 给 %v ", currentTime, nickName(e.User), nickName(e.ToUser)), nil) +
+				e.TrayText.render(style) + style.text(fmt.Sprintf(" X %v，共 %v 钻", e.Count, e.Value), nil)
+		}
+	case MemberEvent:
+		if e.AnchorText.Plain() != "" {
+			return style.text(fmt.Sprintf("%s 【进场消息】[ %v ][ %v ] ", currentTime, userId(e.User), gender(e.User)), nil) +
+				e.AnchorText.render(style)
+		}
+	}
+	return style.text(formatLine(event), nil)
+}
+
+// formatLine 渲染不含富文本的单行文本
+func formatLine(event Event) string {
+	meta := event.Meta()
+	currentTime := meta.Time().Format(enums.TimeFormat)
+	switch e := event.(type) {
+	case AckEvent:
+		return fmt.Sprintf("%s  ACK sent successfully.", currentTime)
+	case ChatEvent:
+		return fmt.Sprintf("%s 【聊天消息】[ %v ] %v : %v", currentTime, userId(e.User), nickName(e.User), e.Content)
+	case EmojiChatEvent:
+		return fmt.Sprintf("%s 【聊天表情包ID】 %v,user：%v,defaultContent:%v", currentTime, e.EmojiId, nickName(e.User), e.DefaultContent)
+	case GiftEvent:
+		// 连击中的每一条只在合并后的 GiftComboEvent 中渲染一次
+		return ""
+	case GiftComboEvent:
+		return fmt.Sprintf("%s 【礼物消息】%v 给 %v 送出了 %v X %v，共 %v 钻", currentTime, nickName(e.User), nickName(e.ToUser), e.GiftName, e.Count, e.Value)
+	case MemberEvent:
+		return fmt.Sprintf("%s 【进场消息】[ %v ][ %v ] %v 进入了直播间", currentTime, userId(e.User), gender(e.User), nickName(e.User))
+	case LikeEvent:
+		return fmt.Sprintf("%s 【点赞消息】【%v】 点了 %v 个赞", currentTime, nickName(e.User), e.Count)
+	case SocialEvent:
+		return fmt.Sprintf("%s 【关注消息】[ %v ] %v 关注了主播", currentTime, userId(e.User), nickName(e.User))
+	case RoomUserSeqEvent:
+		return fmt.Sprintf("%s 【统计消息】当前观看人数: %v , 累计观看人数: %v", currentTime, e.Current, e.TotalPv)
+	case FansclubEvent:
+		return fmt.Sprintf("%s 【粉丝团消息】 %v", currentTime, e.Content)
+	case ControlEvent:
+		if e.LiveEnded() {
+			return fmt.Sprintf("%s 【直播间消息】直播间 %v 已结束", currentTime, e.RoomId)
+		}
+		return ""
+	case RoomStatsEvent:
+		return fmt.Sprintf("%s 【直播间统计消息】%v", currentTime, e.DisplayLong)
+	case RoomEvent:
+		return fmt.Sprintf("%s 【直播间消息】直播间id: %v", currentTime, e.RoomId)
+	case RoomRankEvent:
+		ranks := make([]string, len(e.Ranks))
+		for i, rank := range e.Ranks {
+			ranks[i] = fmt.Sprintf("%d.%v(%v)", i+1, nickName(rank.User), userId(rank.User))
+		}
+		return fmt.Sprintf("%s 【直播间排行榜消息】%v", currentTime, ranks)
+	case LiveShoppingEvent:
+		if e.PromotionId == 0 {
+			return fmt.Sprintf("%s 【购物车消息】结束讲解", currentTime)
+		}
+		return fmt.Sprintf("%s 【购物车消息】正在讲解商品 %v", currentTime, e.PromotionId)
+	case ProductChangeEvent:
+		if e.Toast != "" {
+			return fmt.Sprintf("%s 【商品变化】%v", currentTime, e.Toast)
+		}
+		if explaining := e.Explaining(); explaining != 0 {
+			return fmt.Sprintf("%s 【商品变化】正在讲解商品 %v", currentTime, explaining)
+		}
+		return fmt.Sprintf("%s 【商品变化】%v 个商品、%v 个分类更新，共 %v 个商品", currentTime, len(e.Products), len(e.Categories), e.Total)
+	case MatchScoreEvent:
+		return fmt.Sprintf("%s 【比分消息】%v %v : %v %v", currentTime, e.Left.Name, goal(e.Left), goal(e.Right), e.Right.Name)
+	case FanTicketEvent:
+		return fmt.Sprintf("%s 【音浪消息】本场音浪 %v", currentTime, e.Count)
+	case CommonTextEvent:
+		return fmt.Sprintf("%s 【系统消息】[ %v ] %v", currentTime, e.Scene, e.Text)
+	case DriftEvent:
+		if e.Kind == DriftDecodeErrors {
+			return fmt.Sprintf("%s 【协议变化】%v 解码失败率 %.1f%% (%v/%v)", currentTime, e.Source, e.ErrorRate*100, e.Errors, e.Total)
+		}
+		return fmt.Sprintf("%s 【协议变化】%v 出现未定义的字段 %v (%v)", currentTime, e.Source, e.Field, e.WireType)
+	default:
+		return fmt.Sprintf("%s 【%s】", currentTime, meta.Method)
+	}
+}
+
+func goal(team Team) string {
+	if team.Goal != "" {
+		return team.Goal
+	}
+	return fmt.Sprint(team.Score)
+}
+
+func userId(user *User) uint64 {
+	if user == nil {
+		return 0
+	}
+	return user.Id
+}
+
+func nickName(user *User) string {
+	if user == nil {
+		return ""
+	}
+	return user.NickName
+}
+
+func gender(user *User) string {
+	if user == nil {
+		return "unknown"
+	}
+	switch user.Gender {
+	case 0:
+		return "女"
+	case 1:
+		return "男"
+	default:
+		return "unknown"
+	}
+}
@@ -6,14 +6,18 @@ import (
 	"douyinLiveCollectors/backend/common/enums"
 	"douyinLiveCollectors/backend/common/log"
 	"douyinLiveCollectors/backend/common/message"
-	"douyinLiveCollectors/backend/library/time"
+	"douyinLiveCollectors/backend/common/protodump"
 	"fmt"
 	"github.com/gorilla/websocket"
 	"google.golang.org/protobuf/proto"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 const (
 	SendAckError                 = "SendAckError: %v"
+	SendHeartbeatError           = "SendHeartbeatError: %v"
 	ParsePushFrameError          = "ParsePushFrameError: %v"
 	DecompressPayloadError       = "DecompressPayloadError: %v"
 	ParseResponseError           = "ParseResponseError: %v"
@@ -29,7 +33,14 @@ const (
 	ParseRoomStatsMessageError   = "ParseRoomStatsMessageError: %v"
 	ParseRoomMessageError        = "ParseRoomMessageError: %v"
 	ParseRoomRankMessageError    = "ParseRoomRankMessageError: %v"
+	ParseLiveShoppingError       = "ParseLiveShoppingError: %v"
+	ParseProductChangeError      = "ParseProductChangeError: %v"
+	ParseMatchAgainstScoreError  = "ParseMatchAgainstScoreError: %v"
+	ParseUpdateFanTicketError    = "ParseUpdateFanTicketError: %v"
+	ParseCommonTextError         = "ParseCommonTextError: %v"
 	UnknownMessageError          = "UnknownMessageError: %v"
+	DecodeMessageError           = "DecodeMessageError: %s: %v"
+	CatalogUnknownMessageError   = "CatalogUnknownMessageError: %v"
 )
 
 //const (
@@ -47,46 +58,201 @@ const (
 //	WebcastRoomRankMessage    = "WebcastRoomRankMessage"
 //)
 
-type Result struct {
-	method string
-	Result string
+// Conn 是回写 ack / 心跳帧所需的连接，实现方需保证并发写安全
+type Conn interface {
+	WriteMessage(messageType int, data []byte) error
 }
 
-func Handler(ws *websocket.Conn, payload []byte, out chan<- Result) {
-	resp := parseAndAck(ws, payload, out)
-	go func() {
-		for _, msg := range resp.GetMessagesList() {
-			switch msg.GetMethod() {
-			case enums.WebcastChatMessage:
-				parseChatMessage(msg.GetPayload(), out)
-			case enums.WebcastGiftMessage:
-				parseGiftMessage(msg.GetPayload(), out)
-			case enums.WebcastMemberMessage:
-				parseMemberMessage(msg.GetPayload(), out)
-			case enums.WebcastLikeMessage:
-				parseLikeMessage(msg.GetPayload(), out)
-			case enums.WebcastSocialMessage:
-				parseSocialMessage(msg.GetPayload(), out)
-			case enums.WebcastRoomUserSeqMessage:
-				parseRoomUserSeqMessage(msg.GetPayload(), out)
-			case enums.WebcastFansclubMessage:
-				parseFansclubMessage(msg.GetPayload(), out)
-			case enums.WebcastControlMessage:
-				parseControlMessage(msg.GetPayload(), out)
-				ws.Close()
-			case enums.WebcastEmojiChatMessage:
-				parseEmojiChatMessage(msg.GetPayload(), out)
-			case enums.WebcastRoomStatsMessage:
-				parseRoomStatsMessage(msg.GetPayload(), out)
-			case enums.WebcastRoomMessage:
-				parseRoomMessage(msg.GetPayload(), out)
-			case enums.WebcastRoomRankMessage:
-				parseRoomRankMessage(msg.GetPayload(), out)
-			default:
-				log.Info(UnknownMessageError, msg.String())
+// Handler 负责单个直播间的消息解析，日志写入该直播间自己的日志上下文
+type Handler struct {
+	logger   *log.DefaultLogger
+	registry *Registry
+	buffer   *buffer
+	dedup    *dedup
+	catalog  *protodump.Catalog
+	drift    *drift
+	combos   *combos
+	flushes  chan struct{}
+	repeated atomic.Uint64
+	jobs     chan *job
+	order    chan *job
+	wg       inflight
+	mu       sync.RWMutex
+	closed   bool
+	done     chan struct{}
+	once     sync.Once
+}
+
+// Options 控制分发流水线与输出缓冲，零值使用默认配置
+type Options struct {
+	Workers    int    // 并行解码的协程数，默认 DefaultWorkers
+	BufferSize int    // 输出缓冲的容量，默认 DefaultBufferSize
+	Policy     string // 缓冲区满时的处理策略，默认 PolicySample
+	SampleRate int    // PolicySample 下低价值事件每 SampleRate 条保留一条，默认 DefaultSampleRate
+
+	DedupSize int           // 按 msgId 去重的窗口大小，默认 DefaultDedupSize，< 0 时不去重
+	DedupTTL  time.Duration // 去重窗口的时长，默认 DefaultDedupTTL
+
+	Catalog *protodump.Catalog // 非空时未注册解码器的方法按 wire 格式解析后写入该目录
+}
+
+// NewHandler 使用 DefaultRegistry 的副本，之后可通过 Registry 为该直播间单独注册
+func NewHandler(logger *log.DefaultLogger, out chan<- Event, opts Options) *Handler {
+	h := &Handler{
+		logger:   logger,
+		registry: DefaultRegistry.Clone(),
+		dedup:    newDedup(opts.DedupSize, opts.DedupTTL),
+		catalog:  opts.Catalog,
+		drift:    newDrift(),
+		combos:   newCombos(),
+		flushes:  make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	h.buffer = newBuffer(opts, &h.wg, h.done)
+	go h.buffer.run(out)
+	h.startPipeline(opts.Workers)
+	return h
+}
+
+// OutputStats 返回输出缓冲的计数，包括按消息类型统计的丢弃数量与去重数量
+func (h *Handler) OutputStats() OutputStats {
+	stats := h.buffer.stats()
+	stats.Duplicates = h.repeated.Load()
+	return stats
+}
+
+// DriftReport 返回按方法统计的未知字段与解码失败率
+func (h *Handler) DriftReport() DriftReport {
+	return h.drift.report()
+}
+
+func (h *Handler) Registry() *Registry {
+	return h.registry
+}
+
+// Close 之后尚未送出的消息会被丢弃，不再阻塞在 out 上，分发协程随之退出
+func (h *Handler) Close() {
+	h.once.Do(func() {
+		close(h.done)
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		h.closed = true
+		close(h.order)
+		close(h.jobs)
+		if h.catalog != nil {
+			if err := h.catalog.Flush(); err != nil {
+				h.logger.Error(CatalogUnknownMessageError, err)
 			}
 		}
-	}()
+	})
+}
+
+// Wait 等待已登记的消息全部输出或丢弃，并输出尚未结束的礼物连击，之后调用方可以安全地关闭 out
+func (h *Handler) Wait() {
+	h.wg.Wait()
+	h.flushCombos()
+	h.wg.Wait()
+}
+
+// flushCombos 让输出协程立即输出所有未结束的连击，Close 之后不再输出
+func (h *Handler) flushCombos() {
+	h.wg.Add(1)
+	select {
+	case h.flushes <- struct{}{}:
+	case <-h.done:
+		h.wg.Done()
+	}
+}
+
+// Handle 解析一帧 PushFrame 并交给分发流水线，返回解析出的 Response 供调用方记录 cursor。
+// 同一个 Handler 的 Handle 需在同一个协程中按收到的顺序调用
+func (h *Handler) Handle(ws Conn, payload []byte) *message.Response {
+	resp, logId := h.parseAndAck(ws, payload)
+	if resp == nil {
+		return nil
+	}
+	if resp.GetNeedAck() {
+		h.enqueue(nil, newAckEvent(logId))
+	}
+	for _, msg := range sortByOffset(resp.GetMessagesList()) {
+		h.enqueue(msg, nil)
+	}
+	return resp
+}
+
+// decode 在 worker 中执行，未知方法或解码失败时返回 nil
+func (h *Handler) decode(msg *message.Message) Event {
+	event, known, err := h.registry.Decode(msg)
+	if !known {
+		h.drift.unknownMethod(msg.GetMethod())
+		h.logger.Info(UnknownMessageError, h.dump(msg))
+		return nil
+	}
+	if err != nil {
+		h.drift.failed(msg.GetMethod())
+		h.logger.Info(DecodeMessageError, msg.GetMethod(), err)
+		return nil
+	}
+	if schema := h.registry.Schema(msg.GetMethod()); schema != nil {
+		h.drift.check(msg, schema)
+	} else if !h.registry.Registered(msg.GetMethod()) {
+		// 兜底解码器处理的消息同样计入未知方法并写入目录
+		h.drift.unknownMethod(msg.GetMethod())
+		if h.catalog != nil {
+			h.dump(msg)
+		}
+	}
+	return event
+}
+
+// dump 按 wire 格式解析未知消息用于日志，配置了 Catalog 时同时写入目录
+func (h *Handler) dump(msg *message.Message) string {
+	var fields []protodump.Field
+	var err error
+	if h.catalog != nil {
+		fields, err = h.catalog.Add(msg.GetMethod(), msg.GetPayload(), time.Now())
+	} else {
+		fields, err = protodump.Parse(msg.GetPayload())
+	}
+	if err != nil {
+		if fields == nil {
+			return fmt.Sprintf("%s: %v", msg.GetMethod(), err)
+		}
+		h.logger.Error(CatalogUnknownMessageError, err)
+	}
+	return fmt.Sprintf("%s: %s", msg.GetMethod(), protodump.Compact(fields))
+}
+
+// output 在唯一的输出协程中按序执行，窗口内重复的 msgId 会被跳过
+func (h *Handler) output(event Event) {
+	if h.dedup.duplicate(event.Meta().MsgId, time.Now()) {
+		h.repeated.Add(1)
+		return
+	}
+	if text := FormatText(event); text != "" {
+		h.logger.Info("Received %s", text)
+	}
+	h.registry.notify(event)
+	h.buffer.push(event)
+	if gift, ok := event.(GiftEvent); ok {
+		if combo, done := h.combos.observe(gift, time.Now()); done {
+			h.output(combo)
+		}
+	}
+}
+
+// IsLiveEnded 判断 Response 中是否包含下播(status = 3)的控制消息
+func IsLiveEnded(resp *message.Response) bool {
+	for _, msg := range resp.GetMessagesList() {
+		if msg.GetMethod() != enums.WebcastControlMessage {
+			continue
+		}
+		var control message.ControlMessage
+		if err := proto.Unmarshal(msg.GetPayload(), &control); err == nil && control.GetStatus() == 3 {
+			return true
+		}
+	}
+	return false
 }
 
 func parsePushFrame(payload []byte) (*message.PushFrame, error) {
@@ -122,10 +288,10 @@ func decompressGzip(payload []byte) ([]byte, error) {
 	return decompressed.Bytes(), nil
 }
 
-func sendAck(ws *websocket.Conn, LogId uint64, internalExt string) error {
+func sendAck(ws Conn, LogId uint64, internalExt string) error {
 	ack := &message.PushFrame{
 		LogId:       LogId,
-		PayloadType: "ack",
+		PayloadType: enums.PayloadTypeAck,
 		Payload:     []byte(internalExt),
 	}
 	ackData, err := proto.Marshal(ack)
@@ -140,236 +306,52 @@ func sendAck(ws *websocket.Conn, LogId uint64, internalExt string) error {
 	return nil
 }
 
-func parseAndAck(ws *websocket.Conn, payload []byte, out chan<- Result) *message.Response {
-	pkg, err := parsePushFrame(payload)
-	if err != nil {
-		log.Info(ParsePushFrameError, err.Error())
-	}
-
-	decompressedData, err := decompressGzip(pkg.Payload)
-	if err != nil {
-		log.Error(DecompressPayloadError, err.Error())
+// SendHeartbeat 发送 payloadType 为 hb 的心跳帧
+func SendHeartbeat(ws Conn) error {
+	hb := &message.PushFrame{
+		PayloadType: enums.PayloadTypeHeartbeat,
 	}
-
-	resp, err := parseResponse(decompressedData)
+	hbData, err := proto.Marshal(hb)
 	if err != nil {
-		log.Error(ParseResponseError, err.Error())
-	}
-
-	if resp.NeedAck {
-		err = sendAck(ws, pkg.LogId, resp.InternalExt)
-		if err != nil {
-			log.Info(SendAckError, err.Error())
-		}
-		currentTime := time.Now()
-		log.Info("Received ack message: ACK sent successfully")
-		out <- Result{
-			method: enums.WebcastRoomMessage,
-			Result: fmt.Sprintf("%s  ACK sent successfully.", currentTime),
-		}
-	}
-	return resp
-}
-
-func parseChatMessage(payload []byte, out chan<- Result) {
-	var chat message.ChatMessage
-	err := proto.Unmarshal(payload, &chat)
-	if err != nil {
-		log.Info(ParseChatMessageError, err.Error())
-	}
-	userName := chat.GetUser().GetNickName()
-	userId := chat.GetUser().GetId()
-	content := chat.GetContent()
-	currentTime := time.ParseEventTime(chat.GetEventTime())
-	log.Info("Received chat message : %s (ID: %d): %s", userName, userId, content)
-	out <- Result{
-		method: enums.WebcastChatMessage,
-		Result: fmt.Sprintf("%s 【聊天消息】[ {%v} ] {%v} : {%v}", currentTime, userId, userName, content),
+		return err
 	}
-}
 
-func parseGiftMessage(payload []byte, out chan<- Result) {
-	var gift message.GiftMessage
-	err := proto.Unmarshal(payload, &gift)
+	err = ws.WriteMessage(websocket.BinaryMessage, hbData)
 	if err != nil {
-		log.Info(ParseGiftMessageError, err.Error())
-	}
-	userName := gift.GetUser().GetNickName()
-	toUser := gift.GetToUser().GetNickName()
-	giftName := gift.GetGift().GetName()
-	combo := gift.GetComboCount()
-	currentTime := time.Now()
-	log.Info("Received gift message : %s : to %s : %s X %v combo", userName, toUser, giftName, combo)
-	out <- Result{
-		method: enums.WebcastGiftMessage,
-		Result: fmt.Sprintf("%s 【礼物消息】{%v} 给 {%s} 送出了 {%v} X {%v}连击", currentTime, userName, toUser, giftName, combo),
-	}
-}
-
-func parseMemberMessage(payload []byte, out chan<- Result) {
-	var member message.MemberMessage
-	err := proto.Unmarshal(payload, &member)
-	if err != nil {
-		log.Info(ParseMemberMessageError, err.Error())
-	}
-	userId := member.GetUser().GetId()
-	userName := member.GetUser().GetNickName()
-	gender := []string{"女", "男", "unknown"}[member.GetUser().GetGender()]
-	currentTime := time.Now()
-	log.Info("Received member message : %s (ID: %v, gender: %s) 进入了直播间", userName, userId, gender)
-	out <- Result{
-		method: enums.WebcastMemberMessage,
-		Result: fmt.Sprintf("%s 【进场消息】[ {%v} ][ {%v} ] {%v} 进入了直播间", currentTime, userId, gender, userName),
+		return fmt.Errorf(SendHeartbeatError, err)
 	}
+	return nil
 }
 
-func parseRoomRankMessage(payload []byte, out chan<- Result) {
-	var roomRank message.RoomRankMessage
-	err := proto.Unmarshal(payload, &roomRank)
+func (h *Handler) parseAndAck(ws Conn, payload []byte) (*message.Response, uint64) {
+	pkg, err := parsePushFrame(payload)
 	if err != nil {
-		log.Info(ParseRoomRankMessageError, err.Error())
-	}
-	ranksList := roomRank.GetRanksList()
-	ranks := make(map[int]interface{}, 3)
-	for i, rank := range ranksList {
-		ranks[i] = map[string]interface{}{
-			"Id": rank.GetUser().GetId(),
-			"昵称": rank.GetUser().GetNickName(),
-		}
-	}
-	currentTime := time.Now()
-	log.Info("Received roomRank message : %v", ranks)
-	out <- Result{
-		method: enums.WebcastRoomRankMessage,
-		Result: fmt.Sprintf("%s 【直播间排行榜消息】{%v}", currentTime, ranks),
+		h.logger.Info(ParsePushFrameError, err.Error())
+		return nil, 0
 	}
-}
 
-func parseRoomMessage(payload []byte, out chan<- Result) {
-	var room message.RoomMessage
-	err := proto.Unmarshal(payload, &room)
-	if err != nil {
-		log.Info(ParseRoomMessageError, err.Error())
+	// 心跳回包不携带 gzip 数据
+	if pkg.GetPayloadType() == enums.PayloadTypeHeartbeat {
+		return nil, 0
 	}
-	roomId := room.GetCommon().GetRoomId()
-	currentTime := time.Now()
-	log.Info("Received room message : 直播间id: %v", roomId)
-	out <- Result{
-		method: enums.WebcastRoomMessage,
-		Result: fmt.Sprintf("%s 【直播间消息】直播间id: {%v}", currentTime, roomId),
-	}
-}
 
-func parseRoomStatsMessage(payload []byte, out chan<- Result) {
-	var roomStats message.RoomStatsMessage
-	err := proto.Unmarshal(payload, &roomStats)
+	decompressedData, err := decompressGzip(pkg.Payload)
 	if err != nil {
-		log.Info(ParseRoomStatsMessageError, err.Error())
-	}
-	displayLong := roomStats.GetDisplayLong()
-	currentTime := time.Now()
-	log.Info("Received roomStates message : %v", displayLong)
-	out <- Result{
-		method: enums.WebcastRoomStatsMessage,
-		Result: fmt.Sprintf("%s 【直播间统计消息】{%v}", currentTime, displayLong),
+		h.logger.Error(DecompressPayloadError, err.Error())
+		return nil, 0
 	}
-}
 
-func parseEmojiChatMessage(payload []byte, out chan<- Result) {
-	var emoji message.EmojiChatMessage
-	err := proto.Unmarshal(payload, &emoji)
+	resp, err := parseResponse(decompressedData)
 	if err != nil {
-		log.Info(ParseEmojiChatMessageError, err.Error())
-	}
-	emojiId := emoji.GetEmojiId()
-	userName := emoji.GetUser().GetNickName()
-	//common := emoji.GetCommon()
-	defaultContent := emoji.GetDefaultContent()
-	currentTime := time.Now()
-	log.Info("Received emojiChat message : %s : emojiId: %v,defaultContent: %s", userName, emojiId, defaultContent)
-	out <- Result{
-		method: enums.WebcastEmojiChatMessage,
-		//Result: fmt.Sprintf("%s 【聊天表情包ID】 {%v},user：{%v},common:{%v},defaultContent:{%v}", currentTime, emojiId, userName, common, defaultContent),
-		Result: fmt.Sprintf("%s 【聊天表情包ID】 {%v},user：{%v},defaultContent:{%v}", currentTime, emojiId, userName, defaultContent),
+		h.logger.Error(ParseResponseError, err.Error())
+		return nil, 0
 	}
-}
 
-func parseControlMessage(payload []byte, out chan<- Result) {
-	var control message.ControlMessage
-	err := proto.Unmarshal(payload, &control)
-	if err != nil {
-		log.Info(ParseControlMessageError, err.Error())
-	}
-	if control.GetStatus() == 3 {
-		roomId := control.GetCommon().GetRoomId()
-		currentTime := time.Now()
-		log.Info("Received control message : 直播间 %v 已结束", roomId)
-		out <- Result{
-			method: enums.WebcastControlMessage,
-			Result: fmt.Sprintf("%s 【直播间消息】直播间 {%v} 已结束", currentTime, roomId),
+	if resp.NeedAck {
+		err = sendAck(ws, pkg.LogId, resp.InternalExt)
+		if err != nil {
+			h.logger.Info(SendAckError, err.Error())
 		}
 	}
-}
-
-func parseFansclubMessage(payload []byte, out chan<- Result) {
-	var fansclub message.FansclubMessage
-	err := proto.Unmarshal(payload, &fansclub)
-	if err != nil {
-		log.Info(ParseFansclubMessageError, err.Error())
-	}
-	content := fansclub.GetContent()
-	currentTime := time.Now()
-	log.Info("Received fansclub message : 粉丝团消息: %s", content)
-	out <- Result{
-		method: enums.WebcastFansclubMessage,
-		Result: fmt.Sprintf("%s 【粉丝团消息】 {%v}", currentTime, content),
-	}
-}
-
-func parseRoomUserSeqMessage(payload []byte, out chan<- Result) {
-	var roomUserSeq message.RoomUserSeqMessage
-	err := proto.Unmarshal(payload, &roomUserSeq)
-	if err != nil {
-		log.Info(ParseRoomUserSeqMessageError, err.Error())
-	}
-	current := roomUserSeq.GetTotal()
-	total := roomUserSeq.GetTotalPvForAnchor()
-	currentTime := time.Now()
-	log.Info("Received roomUserSeq message : 当前观看人数: %v , 累计观看人数: %s", current, total)
-	out <- Result{
-		method: enums.WebcastRoomUserSeqMessage,
-		Result: fmt.Sprintf("%s 【统计消息】当前观看人数: {%v} , 累计观看人数: {%s}", currentTime, current, total),
-	}
-}
-
-func parseSocialMessage(payload []byte, out chan<- Result) {
-	var social message.SocialMessage
-	err := proto.Unmarshal(payload, &social)
-	if err != nil {
-		log.Info(ParseSocialMessageError, err.Error())
-	}
-	userName := social.GetUser().GetNickName()
-	userId := social.GetUser().GetId()
-	currentTime := time.Now()
-	log.Info("Received social message : %s (Id: %v) 关注了主播", userName, userId)
-	out <- Result{
-		method: enums.WebcastSocialMessage,
-		Result: fmt.Sprintf("%s 【关注消息】[ {%v} ] {%v} 关注了主播", currentTime, userName, userId),
-	}
-}
-
-func parseLikeMessage(payload []byte, out chan<- Result) {
-	var like message.LikeMessage
-	err := proto.Unmarshal(payload, &like)
-	if err != nil {
-		log.Info(ParseLikeMessageError, err.Error())
-	}
-	userName := like.GetUser().GetNickName()
-	count := like.GetCount()
-	currentTime := time.Now()
-	log.Info("Received like message : %s 点了 %v 个赞", userName, count)
-	out <- Result{
-		method: enums.WebcastLikeMessage,
-		Result: fmt.Sprintf("%s 【点赞消息】【{%v}】 点了 {%v} 个赞", currentTime, userName, count),
-	}
+	return resp, pkg.LogId
 }
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/message"
+	"sort"
+	"sync"
+	"time"
+)
+
+// 分发流水线
+//
+// 顺序保证：
+//   - 同一个 Handler 输出的事件（包括回调的调用顺序）与 Handle 被调用的顺序一致，
+//     即与 websocket 上收到帧的顺序一致；
+//   - 同一帧内的消息按 Message.offset 升序输出，任一消息缺少 offset 时保持服务端下发的顺序；
+//   - ack 事件排在该帧的消息之前。
+//
+// 解码由固定数量的 worker 并行完成，最多 workers * windowPerWorker 条消息处于解码中或等待输出，
+// 超出时 Handle 阻塞，直到前面的消息被取走。
+
+const (
+	DefaultWorkers  = 4
+	windowPerWorker = 64
+)
+
+type job struct {
+	msg    *message.Message
+	result chan Event
+}
+
+func (h *Handler) startPipeline(workers int) {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	h.jobs = make(chan *job, workers)
+	h.order = make(chan *job, workers*windowPerWorker)
+	for i := 0; i < workers; i++ {
+		go h.work()
+	}
+	go h.deliver()
+}
+
+// enqueue 按调用顺序登记 job；event 不为 nil 时无需解码，直接按序输出
+func (h *Handler) enqueue(msg *message.Message, event Event) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if h.closed {
+		return
+	}
+
+	j := &job{msg: msg, result: make(chan Event, 1)}
+	h.wg.Add(1)
+	select {
+	case h.order <- j:
+	case <-h.done:
+		h.wg.Done()
+		return
+	}
+
+	if event != nil {
+		j.result <- event
+		return
+	}
+	select {
+	case h.jobs <- j:
+	case <-h.done:
+	}
+}
+
+func (h *Handler) work() {
+	for j := range h.jobs {
+		j.result <- h.decode(j.msg)
+	}
+}
+
+// deliver 按登记顺序等待解码结果并输出，同时定期输出超时的礼物连击
+func (h *Handler) deliver() {
+	ticker := time.NewTicker(comboCheckEvery)
+	defer ticker.Stop()
+	for {
+		select {
+		case j, ok := <-h.order:
+			if !ok {
+				return
+			}
+			h.complete(j)
+		case now := <-ticker.C:
+			for _, combo := range h.combos.expire(now) {
+				h.output(combo)
+			}
+		case <-h.flushes:
+			for _, combo := range h.combos.flush() {
+				h.output(combo)
+			}
+			h.wg.Done()
+		}
+	}
+}
+
+func (h *Handler) complete(j *job) {
+	defer h.wg.Done()
+	select {
+	case event := <-j.result:
+		if event != nil {
+			h.output(event)
+		}
+		// 解码时产生的协议变化告警随输出流送出，可能略早于触发它的消息
+		for _, warning := range h.drift.take() {
+			h.output(warning)
+		}
+	case <-h.done:
+	}
+}
+
+// inflight 统计已登记但尚未输出或丢弃的事件。与 sync.WaitGroup 不同，
+// 计数为 0 时允许其他协程（如超时的礼物连击）与 Wait 并发地 Add
+type inflight struct {
+	mu   sync.Mutex
+	n    int
+	idle chan struct{} // 有协程在 Wait 时创建，计数归零时关闭
+}
+
+func (f *inflight) Add(delta int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.n += delta
+	if f.n < 0 {
+		panic("handler: negative inflight counter")
+	}
+	if f.n == 0 && f.idle != nil {
+		close(f.idle)
+		f.idle = nil
+	}
+}
+
+func (f *inflight) Done() {
+	f.Add(-1)
+}
+
+func (f *inflight) Wait() {
+	f.mu.Lock()
+	if f.n == 0 {
+		f.mu.Unlock()
+		return
+	}
+	if f.idle == nil {
+		f.idle = make(chan struct{})
+	}
+	idle := f.idle
+	f.mu.Unlock()
+	<-idle
+}
+
+// sortByOffset 在所有消息都带有 offset 时按 offset 稳定排序
+func sortByOffset(messages []*message.Message) []*message.Message {
+	for _, msg := range messages {
+		if msg.GetOffset() <= 0 {
+			return messages
+		}
+	}
+	sorted := append([]*message.Message(nil), messages...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].GetOffset() < sorted[j].GetOffset()
+	})
+	return sorted
+}
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/log"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"fmt"
+	"os"
+	"testing"
+)
+
+type discardConn struct{}
+
+func (discardConn) WriteMessage(int, []byte) error {
+	return nil
+}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "handler-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestPipelinePreservesOrder(t *testing.T) {
+	const frames, perFrame = 50, 20
+
+	out := make(chan Event, frames*(perFrame+1))
+	h := NewHandler(log.NewRoomLogger("pipeline-test"), out, Options{Workers: 8, Policy: PolicyBlock})
+	defer h.Close()
+
+	user := mockserver.NewUser(1, "viewer")
+	for i := 0; i < frames; i++ {
+		messages := make([]*message.Message, perFrame)
+		for j := range messages {
+			messages[j] = mockserver.Chat(user, fmt.Sprintf("%d-%d", i, j))
+		}
+		// 同一帧内倒序下发，输出应按 offset 恢复顺序
+		for l, r := 0, len(messages)-1; l < r; l, r = l+1, r-1 {
+			messages[l], messages[r] = messages[r], messages[l]
+		}
+		frame, err := mockserver.EncodeFrame(uint64(i+1), mockserver.NewResponse(i, messages...))
+		if err != nil {
+			t.Fatal(err)
+		}
+		h.Handle(discardConn{}, frame)
+	}
+	h.Wait()
+	close(out)
+
+	var i, j int
+	for event := range out {
+		if _, ok := event.(AckEvent); ok {
+			if j != 0 {
+				t.Fatalf("ack in the middle of frame %d", i)
+			}
+			continue
+		}
+		chat := event.(ChatEvent)
+		if want := fmt.Sprintf("%d-%d", i, j); chat.Content != want {
+			t.Fatalf("got %q, want %q", chat.Content, want)
+		}
+		if j++; j == perFrame {
+			i, j = i+1, 0
+		}
+	}
+	if i != frames {
+		t.Fatalf("received %d frames, want %d", i, frames)
+	}
+}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/message"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"sync"
+)
+
+// DecodeFunc 将一条 Message 解码为事件，返回 nil 事件表示忽略该消息
+type DecodeFunc func(msg *message.Message) (Event, error)
+
+// CallbackFunc 在事件解码后、写入输出通道前被调用
+type CallbackFunc func(event Event)
+
+// UnknownEvent 是未注册方法的默认事件，Payload 为原始 protobuf 数据
+type UnknownEvent struct {
+	EventMeta
+	Payload []byte
+}
+
+// Registry 保存 Message.method 到解码器与回调的映射，并发安全
+type Registry struct {
+	mu        sync.RWMutex
+	decoders  map[string]DecodeFunc
+	callbacks map[string][]CallbackFunc
+	unknown   DecodeFunc
+	schemas   map[string]protoreflect.MessageType
+}
+
+// DefaultRegistry 包含内置解码器，新建的 Handler 都以它的副本为起点
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	r := &Registry{
+		decoders:  make(map[string]DecodeFunc),
+		callbacks: make(map[string][]CallbackFunc),
+		schemas:   make(map[string]protoreflect.MessageType),
+	}
+	r.Register(enums.WebcastChatMessage, decodeChatMessage)
+	r.Register(enums.WebcastGiftMessage, decodeGiftMessage)
+	r.Register(enums.WebcastMemberMessage, decodeMemberMessage)
+	r.Register(enums.WebcastLikeMessage, decodeLikeMessage)
+	r.Register(enums.WebcastSocialMessage, decodeSocialMessage)
+	r.Register(enums.WebcastRoomUserSeqMessage, decodeRoomUserSeqMessage)
+	r.Register(enums.WebcastFansclubMessage, decodeFansclubMessage)
+	r.Register(enums.WebcastControlMessage, decodeControlMessage)
+	r.Register(enums.WebcastEmojiChatMessage, decodeEmojiChatMessage)
+	r.Register(enums.WebcastRoomStatsMessage, decodeRoomStatsMessage)
+	r.Register(enums.WebcastRoomMessage, decodeRoomMessage)
+	r.Register(enums.WebcastRoomRankMessage, decodeRoomRankMessage)
+	r.Register(enums.WebcastLiveShoppingMessage, decodeLiveShoppingMessage)
+	r.Register(enums.WebcastProductChangeMessage, decodeProductChangeMessage)
+	r.Register(enums.WebcastMatchAgainstScoreMessage, decodeMatchAgainstScoreMessage)
+	r.Register(enums.WebcastUpdateFanTicketMessage, decodeUpdateFanTicketMessage)
+	r.Register(enums.WebcastCommonTextMessage, decodeCommonTextMessage)
+	r.RegisterSchema(enums.WebcastChatMessage, &message.ChatMessage{})
+	r.RegisterSchema(enums.WebcastGiftMessage, &message.GiftMessage{})
+	r.RegisterSchema(enums.WebcastMemberMessage, &message.MemberMessage{})
+	r.RegisterSchema(enums.WebcastLikeMessage, &message.LikeMessage{})
+	r.RegisterSchema(enums.WebcastSocialMessage, &message.SocialMessage{})
+	r.RegisterSchema(enums.WebcastRoomUserSeqMessage, &message.RoomUserSeqMessage{})
+	r.RegisterSchema(enums.WebcastFansclubMessage, &message.FansclubMessage{})
+	r.RegisterSchema(enums.WebcastControlMessage, &message.ControlMessage{})
+	r.RegisterSchema(enums.WebcastEmojiChatMessage, &message.EmojiChatMessage{})
+	r.RegisterSchema(enums.WebcastRoomStatsMessage, &message.RoomStatsMessage{})
+	r.RegisterSchema(enums.WebcastRoomMessage, &message.RoomMessage{})
+	r.RegisterSchema(enums.WebcastRoomRankMessage, &message.RoomRankMessage{})
+	r.RegisterSchema(enums.WebcastLiveShoppingMessage, &message.LiveShoppingMessage{})
+	r.RegisterSchema(enums.WebcastProductChangeMessage, &message.ProductChangeMessage{})
+	r.RegisterSchema(enums.WebcastMatchAgainstScoreMessage, &message.MatchAgainstScoreMessage{})
+	r.RegisterSchema(enums.WebcastUpdateFanTicketMessage, &message.UpdateFanTicketMessage{})
+	r.RegisterSchema(enums.WebcastCommonTextMessage, &message.CommonTextMessage{})
+	return r
+}
+
+// Register 注册或覆盖某个方法的解码器，decode 为 nil 时移除该方法
+func (r *Registry) Register(method string, decode DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if decode == nil {
+		delete(r.decoders, method)
+		return
+	}
+	r.decoders[method] = decode
+}
+
+// RegisterUnknown 为所有未注册的方法设置兜底解码器，为 nil 时仅记录日志
+func (r *Registry) RegisterUnknown(decode DecodeFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unknown = decode
+}
+
+// RegisterSchema 声明某个方法 payload 对应的 proto 消息，用于检测未知字段，schema 为 nil 时移除
+func (r *Registry) RegisterSchema(method string, schema proto.Message) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if schema == nil {
+		delete(r.schemas, method)
+		return
+	}
+	r.schemas[method] = schema.ProtoReflect().Type()
+}
+
+// Schema 返回方法对应的 proto 消息类型，未声明时返回 nil
+func (r *Registry) Schema(method string) protoreflect.MessageType {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.schemas[method]
+}
+
+// On 为某个方法追加回调，method 为空字符串时对所有事件生效
+func (r *Registry) On(method string, callback CallbackFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.callbacks[method] = append(r.callbacks[method], callback)
+}
+
+// Methods 返回已注册解码器的方法名
+func (r *Registry) Methods() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	methods := make([]string, 0, len(r.decoders))
+	for method := range r.decoders {
+		methods = append(methods, method)
+	}
+	return methods
+}
+
+// Registered 判断方法是否注册了解码器，不包括兜底解码器
+func (r *Registry) Registered(method string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.decoders[method]
+	return ok
+}
+
+// Clone 复制当前的解码器与回调，之后两者的修改互不影响
+func (r *Registry) Clone() *Registry {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c := &Registry{
+		decoders:  make(map[string]DecodeFunc, len(r.decoders)),
+		callbacks: make(map[string][]CallbackFunc, len(r.callbacks)),
+		unknown:   r.unknown,
+		schemas:   make(map[string]protoreflect.MessageType, len(r.schemas)),
+	}
+	for method, schema := range r.schemas {
+		c.schemas[method] = schema
+	}
+	for method, decode := range r.decoders {
+		c.decoders[method] = decode
+	}
+	for method, callbacks := range r.callbacks {
+		c.callbacks[method] = append([]CallbackFunc(nil), callbacks...)
+	}
+	return c
+}
+
+// Decode 查找解码器并解码，known 为 false 表示该方法未注册且没有兜底解码器
+func (r *Registry) Decode(msg *message.Message) (event Event, known bool, err error) {
+	r.mu.RLock()
+	decode, ok := r.decoders[msg.GetMethod()]
+	if !ok {
+		decode = r.unknown
+	}
+	r.mu.RUnlock()
+	if decode == nil {
+		return nil, false, nil
+	}
+	event, err = decode(msg)
+	return event, true, err
+}
+
+func (r *Registry) notify(event Event) {
+	r.mu.RLock()
+	method := event.Meta().Method
+	callbacks := make([]CallbackFunc, 0, len(r.callbacks[method])+len(r.callbacks[""]))
+	callbacks = append(callbacks, r.callbacks[method]...)
+	callbacks = append(callbacks, r.callbacks[""]...)
+	r.mu.RUnlock()
+	for _, callback := range callbacks {
+		callback(event)
+	}
+}
+
+// DecodeUnknown 可作为 RegisterUnknown 的参数，把未知消息作为 UnknownEvent 输出
+func DecodeUnknown(msg *message.Message) (Event, error) {
+	return UnknownEvent{
+		EventMeta: newMeta(msg, nil),
+		Payload:   msg.GetPayload(),
+	}, nil
+}
+
+// Register 在 DefaultRegistry 上注册解码器，只影响之后创建的 Handler
+func Register(method string, decode DecodeFunc) {
+	DefaultRegistry.Register(method, decode)
+}
+
+// RegisterUnknown 在 DefaultRegistry 上设置兜底解码器，只影响之后创建的 Handler
+func RegisterUnknown(decode DecodeFunc) {
+	DefaultRegistry.RegisterUnknown(decode)
+}
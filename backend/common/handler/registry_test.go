@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"testing"
+)
+
+type customEvent struct {
+	EventMeta
+	Size int
+}
+
+func TestRegistryOverrideAndUnknown(t *testing.T) {
+	r := NewRegistry()
+	r.Register(enums.WebcastLikeMessage, func(msg *message.Message) (Event, error) {
+		return customEvent{EventMeta: newMeta(msg, nil), Size: len(msg.GetPayload())}, nil
+	})
+	var seen []string
+	r.On("", func(event Event) {
+		seen = append(seen, event.Meta().Method)
+	})
+
+	event, known, err := r.Decode(mockserver.Like(mockserver.NewUser(1, "a"), 1))
+	if _, ok := event.(customEvent); !ok || !known || err != nil {
+		t.Fatalf("Decode = %T, %v, %v", event, known, err)
+	}
+	r.notify(event)
+
+	unknown := &message.Message{Method: "WebcastNewMessage", Payload: []byte{1}}
+	if _, known, _ := r.Decode(unknown); known {
+		t.Fatal("unknown method decoded without catch-all")
+	}
+	r.RegisterUnknown(DecodeUnknown)
+	event, known, _ = r.Decode(unknown)
+	if e, ok := event.(UnknownEvent); !ok || !known || e.Method != "WebcastNewMessage" {
+		t.Fatalf("catch-all Decode = %+v, %v", event, known)
+	}
+
+	if len(seen) != 1 || seen[0] != enums.WebcastLikeMessage {
+		t.Fatalf("callbacks = %v", seen)
+	}
+	if _, ok := DefaultRegistry.Clone().decoders["WebcastNewMessage"]; ok {
+		t.Fatal("registry changes leaked into DefaultRegistry")
+	}
+}
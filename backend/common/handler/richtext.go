@@ -0,0 +1,300 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/message"
+	"html"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// 富文本渲染
+//
+// Text.defaultPatter 形如 "{0:user} 送出了 {1:gift} × {2:string}"，{n:类型} 引用 piecesList 中的第 n 项，
+// 类型仅作提示，实际内容以片段中有值的字段为准。引用不存在的片段时保留原文。
+
+// TextFormat 是文字样式，Color 为 "#RRGGBB" 或带透明度的 "#AARRGGBB"
+type TextFormat struct {
+	Color    string
+	Bold     bool
+	Italic   bool
+	FontSize uint32
+}
+
+// TextPiece 是富文本中的一个片段，按 Text、User、Gift、Heart、Image 的顺序取第一个有值的字段
+type TextPiece struct {
+	Format    *TextFormat // 为 nil 时使用 RichText.Format
+	Text      string
+	User      *User
+	WithColon bool
+	GiftId    uint64
+	GiftName  string
+	Heart     bool
+	Image     string
+	ImageAlt  string
+}
+
+// RichText 是解析后的 Text，nil 表示消息中没有该字段
+type RichText struct {
+	Key     string
+	Pattern string
+	Format  *TextFormat
+	Pieces  []TextPiece
+}
+
+func newTextFormat(format *message.TextFormat) *TextFormat {
+	if format == nil {
+		return nil
+	}
+	return &TextFormat{
+		Color:    format.GetColor(),
+		Bold:     format.GetBold() || format.GetWeight() >= 700,
+		Italic:   format.GetItalic(),
+		FontSize: format.GetFontSize(),
+	}
+}
+
+// NewRichText 转换消息中的 Text，text 为 nil 时返回 nil
+func NewRichText(text *message.Text) *RichText {
+	if text == nil {
+		return nil
+	}
+	rich := &RichText{
+		Key:     text.GetKey(),
+		Pattern: text.GetDefaultPatter(),
+		Format:  newTextFormat(text.GetDefaultFormat()),
+		Pieces:  make([]TextPiece, 0, len(text.GetPiecesList())),
+	}
+	for _, piece := range text.GetPiecesList() {
+		p := TextPiece{
+			Format:    newTextFormat(piece.GetFormat()),
+			Text:      piece.GetStringValue(),
+			User:      newUser(piece.GetUserValue().GetUser()),
+			WithColon: piece.GetUserValue().GetWithColon(),
+			GiftId:    piece.GetGiftValue().GetGiftId(),
+			GiftName:  piece.GetGiftValue().GetNameRef().GetDefaultPattern(),
+			Heart:     piece.GetHeartValue() != nil,
+			Image:     imageUrl(piece.GetImageValue().GetImage()),
+			ImageAlt:  piece.GetImageValue().GetImage().GetContent().GetAlternativeText(),
+		}
+		if p.Text == "" {
+			p.Text = piece.GetPatternRefValue().GetDefaultPattern()
+		}
+		if p.Heart && p.Format == nil {
+			p.Format = &TextFormat{Color: piece.GetHeartValue().GetColor()}
+		}
+		rich.Pieces = append(rich.Pieces, p)
+	}
+	return rich
+}
+
+// Plain 渲染为纯文本，图片输出替代文本
+func (t *RichText) Plain() string {
+	return t.render(plainStyle{})
+}
+
+// HTML 渲染为 HTML 片段，样式写在 span 的 style 中
+func (t *RichText) HTML() string {
+	return t.render(htmlStyle{})
+}
+
+// Markdown 渲染为 Markdown，颜色与字号会被忽略
+func (t *RichText) Markdown() string {
+	return t.render(markdownStyle{})
+}
+
+// textStyle 决定文字与图片的输出方式
+type textStyle interface {
+	text(s string, format *TextFormat) string
+	image(url, alt string, format *TextFormat) string
+}
+
+func (t *RichText) render(style textStyle) string {
+	if t == nil {
+		return ""
+	}
+	var b strings.Builder
+	if t.Pattern == "" {
+		for i := range t.Pieces {
+			b.WriteString(t.piece(i, style))
+		}
+		return b.String()
+	}
+	// 相邻的原文合并后再渲染
+	var literal strings.Builder
+	flush := func() {
+		if literal.Len() > 0 {
+			b.WriteString(style.text(literal.String(), t.Format))
+			literal.Reset()
+		}
+	}
+	rest := t.Pattern
+	for rest != "" {
+		start := strings.IndexByte(rest, '{')
+		end := -1
+		if start >= 0 {
+			end = strings.IndexByte(rest[start:], '}')
+		}
+		if end < 0 {
+			literal.WriteString(rest)
+			break
+		}
+		end += start
+		literal.WriteString(rest[:start])
+		ref := rest[start+1 : end]
+		if i := strings.IndexByte(ref, ':'); i >= 0 {
+			ref = ref[:i]
+		}
+		if index, err := strconv.Atoi(ref); err == nil && index >= 0 && index < len(t.Pieces) {
+			flush()
+			b.WriteString(t.piece(index, style))
+		} else {
+			literal.WriteString(rest[start : end+1])
+		}
+		rest = rest[end+1:]
+	}
+	flush()
+	return b.String()
+}
+
+func (t *RichText) piece(index int, style textStyle) string {
+	p := t.Pieces[index]
+	format := p.Format
+	if format == nil {
+		format = t.Format
+	}
+	switch {
+	case p.Text != "":
+		return style.text(p.Text, format)
+	case p.User != nil:
+		if p.WithColon {
+			return style.text(p.User.NickName+"：", format)
+		}
+		return style.text(p.User.NickName, format)
+	case p.GiftName != "":
+		return style.text(p.GiftName, format)
+	case p.GiftId != 0:
+		return style.text(strconv.FormatUint(p.GiftId, 10), format)
+	case p.Heart:
+		return style.text("❤", format)
+	case p.Image != "" || p.ImageAlt != "":
+		return style.image(p.Image, p.ImageAlt, format)
+	}
+	return ""
+}
+
+type plainStyle struct{}
+
+func (plainStyle) text(s string, _ *TextFormat) string {
+	return s
+}
+
+func (plainStyle) image(_, alt string, _ *TextFormat) string {
+	if alt == "" {
+		return "[图片]"
+	}
+	return alt
+}
+
+type htmlStyle struct{}
+
+func (htmlStyle) text(s string, format *TextFormat) string {
+	s = html.EscapeString(s)
+	css := cssStyle(format)
+	if css == "" {
+		return s
+	}
+	return `<span style="` + css + `">` + s + `</span>`
+}
+
+func (h htmlStyle) image(url, alt string, format *TextFormat) string {
+	if !webUrl(url) {
+		return h.text(plainStyle{}.image(url, alt, format), format)
+	}
+	var size string
+	if format != nil && format.FontSize > 0 {
+		// 表情等图片与文字同高
+		size = ` style="height:` + strconv.Itoa(int(format.FontSize)) + `px"`
+	}
+	return `<img` + size + ` src="` + html.EscapeString(url) + `" alt="` + html.EscapeString(alt) + `">`
+}
+
+func cssStyle(format *TextFormat) string {
+	if format == nil {
+		return ""
+	}
+	var rules []string
+	if color := cssColor(format.Color); color != "" {
+		rules = append(rules, "color:"+color)
+	}
+	if format.Bold {
+		rules = append(rules, "font-weight:bold")
+	}
+	if format.Italic {
+		rules = append(rules, "font-style:italic")
+	}
+	if format.FontSize > 0 {
+		rules = append(rules, "font-size:"+strconv.Itoa(int(format.FontSize))+"px")
+	}
+	return strings.Join(rules, ";")
+}
+
+// cssColor 把 "#AARRGGBB" 转为 CSS 的 "#RRGGBBAA"，不是合法颜色时返回空字符串
+func cssColor(color string) string {
+	hex := strings.TrimPrefix(color, "#")
+	if _, err := strconv.ParseUint(hex, 16, 32); err != nil {
+		return ""
+	}
+	switch len(hex) {
+	case 3, 6:
+		return "#" + hex
+	case 8:
+		return "#" + hex[2:] + hex[:2]
+	}
+	return ""
+}
+
+type markdownStyle struct{}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "*", `\*`, "_", `\_`, "`", "\\`", "[", `\[`, "]", `\]`, "#", `\#`, "<", `\<`, ">", `\>`,
+)
+
+func (markdownStyle) text(s string, format *TextFormat) string {
+	s = markdownEscaper.Replace(s)
+	trimmed := strings.TrimSpace(s)
+	if format == nil || trimmed == "" {
+		return s
+	}
+	marked := trimmed
+	if format.Italic {
+		marked = "*" + marked + "*"
+	}
+	if format.Bold {
+		marked = "**" + marked + "**"
+	}
+	// 标记不能紧贴空白，保留原有的首尾空白
+	return strings.Replace(s, trimmed, marked, 1)
+}
+
+// markdownUrlEscaper 编码会截断链接目标的字符
+var markdownUrlEscaper = strings.NewReplacer(
+	" ", "%20", "(", "%28", ")", "%29", "<", "%3C", ">", "%3E", "\n", "%0A", "\r", "%0D",
+)
+
+func (markdownStyle) image(url, alt string, _ *TextFormat) string {
+	if !webUrl(url) {
+		return markdownEscaper.Replace(alt)
+	}
+	return "![" + markdownEscaper.Replace(alt) + "](" + markdownUrlEscaper.Replace(url) + ")"
+}
+
+// webUrl 判断图片地址是否为 http 或 https 链接，其它协议（如 javascript:）不输出
+func webUrl(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return false
+	}
+	scheme := strings.ToLower(u.Scheme)
+	return (scheme == "http" || scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"strings"
+	"testing"
+)
+
+func TestRichText(t *testing.T) {
+	msg := mockserver.NewMessage(enums.WebcastMemberMessage, &message.MemberMessage{
+		Common: mockserver.NewCommon(enums.WebcastMemberMessage),
+		User:   mockserver.NewUser(42, "viewer"),
+		AnchorDisplayText: &message.Text{
+			DefaultPatter: "{0:user} 送出 {1:gift} {2:image} x{3:string} {9:string}",
+			DefaultFormat: &message.TextFormat{Color: "#FFFFFF"},
+			PiecesList: []*message.TextPiece{
+				{UserValue: &message.TextPieceUser{User: mockserver.NewUser(42, "<viewer>")}, Format: &message.TextFormat{Color: "#CCFFE27F", Bold: true}},
+				{GiftValue: &message.TextPieceGift{GiftId: 1, NameRef: &message.PatternRef{DefaultPattern: "小心心"}}},
+				{ImageValue: &message.TextPieceImage{Image: &message.Image{
+					UrlListList: []string{"https://example.com/heart.png"},
+					Content:     &message.ImageContent{AlternativeText: "爱心"},
+				}}},
+				{StringValue: "3", Format: &message.TextFormat{Italic: true}},
+			},
+		},
+	})
+	event, _, err := NewRegistry().Decode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := event.(MemberEvent).AnchorText
+
+	if got, want := text.Plain(), "<viewer> 送出 小心心 爱心 x3 {9:string}"; got != want {
+		t.Fatalf("Plain = %q, want %q", got, want)
+	}
+	wantHTML := `<span style="color:#FFE27FCC;font-weight:bold">&lt;viewer&gt;</span>` +
+		`<span style="color:#FFFFFF"> 送出 </span><span style="color:#FFFFFF">小心心</span>` +
+		`<span style="color:#FFFFFF"> </span><img src="https://example.com/heart.png" alt="爱心">` +
+		`<span style="color:#FFFFFF"> x</span><span style="font-style:italic">3</span>` +
+		`<span style="color:#FFFFFF"> {9:string}</span>`
+	if got := text.HTML(); got != wantHTML {
+		t.Fatalf("HTML = %q, want %q", got, wantHTML)
+	}
+	if got, want := text.Markdown(), `**\<viewer\>** 送出 小心心 ![爱心](https://example.com/heart.png) x*3* {9:string}`; got != want {
+		t.Fatalf("Markdown = %q, want %q", got, want)
+	}
+	if (*RichText)(nil).Plain() != "" {
+		t.Fatal("nil RichText should render empty")
+	}
+}
+
+func TestRichTextImageUrl(t *testing.T) {
+	image := func(url string) *RichText {
+		return &RichText{Pieces: []TextPiece{{Image: url, ImageAlt: "图"}}}
+	}
+	if got, want := image("javascript:alert(1)").HTML(), "图"; got != want {
+		t.Fatalf("HTML = %q, want %q", got, want)
+	}
+	if got, want := image(`data:image/png;base64,AAAA`).Markdown(), "图"; got != want {
+		t.Fatalf("Markdown = %q, want %q", got, want)
+	}
+	if got, want := image("https://example.com/a b(1).png").Markdown(), "![图](https://example.com/a%20b%281%29.png)"; got != want {
+		t.Fatalf("Markdown = %q, want %q", got, want)
+	}
+	if got, want := image("HTTP://example.com/a.png").HTML(), `<img src="HTTP://example.com/a.png" alt="图">`; got != want {
+		t.Fatalf("HTML = %q, want %q", got, want)
+	}
+}
+
+func TestFormatRichText(t *testing.T) {
+	anchor := &RichText{
+		Pattern: "{0:user} 来了",
+		Pieces:  []TextPiece{{User: &User{NickName: "<viewer>"}, Format: &TextFormat{Bold: true}}},
+	}
+	member := MemberEvent{User: &User{Id: 42, NickName: "<viewer>", Gender: 1}, AnchorText: anchor}
+	if got, want := FormatText(member), "【进场消息】[ 42 ][ 男 ] <viewer> 来了"; !strings.HasSuffix(got, want) {
+		t.Fatalf("FormatText = %q, want suffix %q", got, want)
+	}
+	if got, want := FormatHTML(member), `【进场消息】[ 42 ][ 男 ] <span style="font-weight:bold">&lt;viewer&gt;</span> 来了`; !strings.HasSuffix(got, want) {
+		t.Fatalf("FormatHTML = %q, want suffix %q", got, want)
+	}
+	if got, want := FormatMarkdown(member), `【进场消息】\[ 42 \]\[ 男 \] **\<viewer\>** 来了`; !strings.HasSuffix(got, want) {
+		t.Fatalf("FormatMarkdown = %q, want suffix %q", got, want)
+	}
+	// 没有富文本的消息同样转义
+	chat := ChatEvent{User: &User{Id: 1, NickName: "a&b"}, Content: "<b>hi</b>"}
+	if got, want := FormatHTML(chat), "a&amp;b : &lt;b&gt;hi&lt;/b&gt;"; !strings.HasSuffix(got, want) {
+		t.Fatalf("FormatHTML = %q, want suffix %q", got, want)
+	}
+	if FormatHTML(GiftEvent{}) != "" {
+		t.Fatal("events without text should stay empty")
+	}
+}
@@ -159,6 +159,38 @@ func (l *DefaultLogger) SetLiveId(liveId string) {
 	l.liveId = liveId
 }
 
+// NewRoomLogger 为单个直播间创建独立的日志上下文，日志写入 logs/<date>/<liveId>/ 下
+func NewRoomLogger(liveId string) *DefaultLogger {
+	l := NewLogger()
+	l.liveId = liveId
+	return l
+}
+
+func (l *DefaultLogger) Debug(format string, a ...any) {
+	l.writeLog(slog.LevelDebug, fmt.Sprintf(format, a...))
+}
+
+func (l *DefaultLogger) Info(format string, a ...any) {
+	l.writeLog(slog.LevelInfo, fmt.Sprintf(format, a...))
+}
+
+func (l *DefaultLogger) Warn(format string, a ...any) {
+	l.writeLog(slog.LevelWarn, fmt.Sprintf(format, a...))
+}
+
+func (l *DefaultLogger) Error(format string, a ...any) {
+	l.writeLog(slog.LevelError, fmt.Sprintf(format, a...))
+}
+
+func (l *DefaultLogger) Close() {
+	mutex.Lock()
+	defer mutex.Unlock()
+	if l.file != nil {
+		l.file.Close()
+		l.file = nil
+	}
+}
+
 func GetLogger() *DefaultLogger {
 	initOnce.Do(func() {
 		Logger = NewLogger()
@@ -167,17 +199,17 @@ func GetLogger() *DefaultLogger {
 }
 
 func Debug(format string, a ...any) {
-	Logger.writeLog(slog.LevelDebug, fmt.Sprintf(format, a...))
+	GetLogger().Debug(format, a...)
 }
 
 func Info(format string, a ...any) {
-	Logger.writeLog(slog.LevelInfo, fmt.Sprintf(format, a...))
+	GetLogger().Info(format, a...)
 }
 
 func Warn(format string, a ...any) {
-	Logger.writeLog(slog.LevelWarn, fmt.Sprintf(format, a...))
+	GetLogger().Warn(format, a...)
 }
 
 func Error(format string, a ...any) {
-	Logger.writeLog(slog.LevelError, fmt.Sprintf(format, a...))
+	GetLogger().Error(format, a...)
 }
@@ -0,0 +1,94 @@
+package mockserver
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/message"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+)
+
+var msgId atomic.Int64
+
+// NewResponse 把若干消息打包为需要 ack 的 Response，cursor / internalExt 按序号生成
+func NewResponse(seq int, messages ...*message.Message) *message.Response {
+	return &message.Response{
+		MessagesList:      messages,
+		Cursor:            fmt.Sprintf("mock-cursor-%d", seq),
+		InternalExt:       fmt.Sprintf("mock-internal-ext-%d", seq),
+		Now:               uint64(time.Now().UnixMilli()),
+		HeartbeatDuration: 10000,
+		NeedAck:           true,
+	}
+}
+
+// NewMessage 将具体消息序列化为 Message.payload
+func NewMessage(method string, payload proto.Message) *message.Message {
+	data, err := proto.Marshal(payload)
+	if err != nil {
+		panic(err)
+	}
+	id := msgId.Add(1)
+	return &message.Message{
+		Method:  method,
+		Payload: data,
+		MsgId:   id,
+		Offset:  id,
+	}
+}
+
+func NewCommon(method string) *message.Common {
+	return &message.Common{
+		Method:     method,
+		MsgId:      uint64(msgId.Load() + 1),
+		RoomId:     7400000000000000001,
+		CreateTime: uint64(time.Now().UnixMilli()),
+	}
+}
+
+func NewUser(id uint64, nickName string) *message.User {
+	return &message.User{Id: id, NickName: nickName}
+}
+
+func Chat(user *message.User, content string) *message.Message {
+	return NewMessage(enums.WebcastChatMessage, &message.ChatMessage{
+		Common:    NewCommon(enums.WebcastChatMessage),
+		User:      user,
+		Content:   content,
+		EventTime: uint64(time.Now().Unix()),
+	})
+}
+
+func Gift(user *message.User, giftName string, comboCount uint64) *message.Message {
+	return NewMessage(enums.WebcastGiftMessage, &message.GiftMessage{
+		Common:     NewCommon(enums.WebcastGiftMessage),
+		User:       user,
+		Gift:       &message.GiftStruct{Name: giftName},
+		ComboCount: comboCount,
+	})
+}
+
+func Like(user *message.User, count uint64) *message.Message {
+	return NewMessage(enums.WebcastLikeMessage, &message.LikeMessage{
+		Common: NewCommon(enums.WebcastLikeMessage),
+		User:   user,
+		Count:  count,
+	})
+}
+
+func Member(user *message.User) *message.Message {
+	return NewMessage(enums.WebcastMemberMessage, &message.MemberMessage{
+		Common: NewCommon(enums.WebcastMemberMessage),
+		User:   user,
+	})
+}
+
+// LiveEnded 返回 status = 3 的下播控制消息
+func LiveEnded() *message.Message {
+	return NewMessage(enums.WebcastControlMessage, &message.ControlMessage{
+		Common: NewCommon(enums.WebcastControlMessage),
+		Status: 3,
+	})
+}
@@ -0,0 +1,273 @@
+// Package mockserver 在本地模拟 live.douyin.com：下发 ttwid、返回包含 roomId 的直播间页面，
+// 并提供推送 gzip 压缩 PushFrame / Response 的 websocket 接口，用于离线端到端测试
+package mockserver
+
+import (
+	"bytes"
+	"compress/gzip"
+	"douyinLiveCollectors/backend/common/config"
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/message"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	TtwId           = "mock-ttwid"
+	RoomId          = "7400000000000000001"
+	PayloadTypeMsg  = "msg"
+	roomPageFormat  = `<html><script>self.__pace_f.push([1,"{\"state\":{\"roomStore\":{\"roomInfo\":{\"roomId\":\"%s\"}}}}"])</script></html>`
+	defaultWaitTime = 5 * time.Second
+)
+
+// Ack 记录客户端回传的一次 ack，Valid 表示 logId 与 internalExt 均与下发的帧一致
+type Ack struct {
+	LogId       uint64
+	InternalExt string
+	Valid       bool
+}
+
+type Server struct {
+	*httptest.Server
+	// Rooms 为 liveId 到 roomId 的映射，未配置的 liveId 返回不含 roomId 的页面
+	Rooms map[string]string
+
+	mu          sync.Mutex
+	fixtures    []*message.Response
+	pending     map[uint64]string
+	acks        []Ack
+	heartbeats  int
+	connections []url.Values
+	conns       []*websocket.Conn
+	stalled     map[*websocket.Conn]bool
+	logId       uint64
+	upgrader    websocket.Upgrader
+	ackNotify   chan struct{}
+}
+
+// New 启动模拟服务，每个新建立的 websocket 连接都会依次收到 fixtures 中的 Response
+func New(fixtures ...*message.Response) *Server {
+	s := &Server{
+		Rooms:     map[string]string{},
+		fixtures:  fixtures,
+		pending:   map[uint64]string{},
+		stalled:   map[*websocket.Conn]bool{},
+		ackNotify: make(chan struct{}, 1),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(enums.WssPath, s.serveWebsocket)
+	mux.HandleFunc("/", s.servePage)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// Config 返回指向本模拟服务的采集配置
+func (s *Server) Config() config.Config {
+	cfg := config.Default()
+	cfg.WebHost = s.URL + "/"
+	cfg.WssHost = "ws" + strings.TrimPrefix(s.URL, "http")
+	cfg.RequestTimeout = 5
+	cfg.DialTimeout = 5
+	return cfg
+}
+
+func (s *Server) Close() {
+	s.DropConnections()
+	s.Server.Close()
+}
+
+// Push 向所有已建立且未假死的连接推送一个 Response
+func (s *Server) Push(resp *message.Response) error {
+	s.mu.Lock()
+	var conns []*websocket.Conn
+	for _, ws := range s.conns {
+		if !s.stalled[ws] {
+			conns = append(conns, ws)
+		}
+	}
+	s.mu.Unlock()
+	for _, ws := range conns {
+		if err := s.push(ws, resp); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DropConnections 断开所有 websocket 连接，用于模拟网络中断
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	conns := s.conns
+	s.conns = nil
+	s.mu.Unlock()
+	for _, ws := range conns {
+		ws.Close()
+	}
+}
+
+// Stall 让已建立的连接假死：保持连接但不再回应心跳、不再推送，新建立的连接不受影响
+func (s *Server) Stall() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, ws := range s.conns {
+		s.stalled[ws] = true
+	}
+}
+
+// Connections 返回每次 websocket 握手时的 query 参数
+func (s *Server) Connections() []url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]url.Values(nil), s.connections...)
+}
+
+func (s *Server) Acks() []Ack {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Ack(nil), s.acks...)
+}
+
+// Heartbeats 返回收到的心跳帧总数，包括假死连接收到的
+func (s *Server) Heartbeats() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.heartbeats
+}
+
+// WaitAcks 等待至少收到 n 个 ack，超时返回 false
+func (s *Server) WaitAcks(n int) bool {
+	deadline := time.After(defaultWaitTime)
+	for {
+		if len(s.Acks()) >= n {
+			return true
+		}
+		select {
+		case <-s.ackNotify:
+		case <-deadline:
+			return false
+		}
+	}
+}
+
+func (s *Server) servePage(w http.ResponseWriter, r *http.Request) {
+	http.SetCookie(w, &http.Cookie{Name: "ttwid", Value: TtwId, Path: "/"})
+	liveId := strings.Trim(r.URL.Path, "/")
+	if liveId == "" {
+		fmt.Fprint(w, "<html></html>")
+		return
+	}
+	roomId, ok := s.Rooms[liveId]
+	if !ok {
+		fmt.Fprint(w, "<html>直播间不存在</html>")
+		return
+	}
+	fmt.Fprintf(w, roomPageFormat, roomId)
+}
+
+func (s *Server) serveWebsocket(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("signature") == "" || !strings.Contains(r.Header.Get("Cookie"), "ttwid="+TtwId) {
+		http.Error(w, "forbidden", http.StatusForbidden)
+		return
+	}
+	ws, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	s.connections = append(s.connections, r.URL.Query())
+	s.conns = append(s.conns, ws)
+	fixtures := s.fixtures
+	s.mu.Unlock()
+
+	go s.read(ws)
+	for _, resp := range fixtures {
+		if err := s.push(ws, resp); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) push(ws *websocket.Conn, resp *message.Response) error {
+	s.mu.Lock()
+	s.logId++
+	logId := s.logId
+	if resp.GetNeedAck() {
+		s.pending[logId] = resp.GetInternalExt()
+	}
+	s.mu.Unlock()
+
+	frame, err := EncodeFrame(logId, resp)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return ws.WriteMessage(websocket.BinaryMessage, frame)
+}
+
+// EncodeFrame 将 Response 按线上格式 gzip 压缩并封装为 PushFrame
+func EncodeFrame(logId uint64, resp *message.Response) ([]byte, error) {
+	data, err := proto.Marshal(resp)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&message.PushFrame{
+		LogId:           logId,
+		PayloadEncoding: "gzip",
+		PayloadType:     PayloadTypeMsg,
+		Payload:         buf.Bytes(),
+	})
+}
+
+// read 校验客户端回传的 ack / 心跳帧
+func (s *Server) read(ws *websocket.Conn) {
+	for {
+		_, data, err := ws.ReadMessage()
+		if err != nil {
+			return
+		}
+		var frame message.PushFrame
+		if err := proto.Unmarshal(data, &frame); err != nil {
+			continue
+		}
+		switch frame.GetPayloadType() {
+		case enums.PayloadTypeAck:
+			s.mu.Lock()
+			internalExt, ok := s.pending[frame.GetLogId()]
+			valid := ok && internalExt == string(frame.GetPayload())
+			delete(s.pending, frame.GetLogId())
+			s.acks = append(s.acks, Ack{LogId: frame.GetLogId(), InternalExt: string(frame.GetPayload()), Valid: valid})
+			s.mu.Unlock()
+			select {
+			case s.ackNotify <- struct{}{}:
+			default:
+			}
+		case enums.PayloadTypeHeartbeat:
+			s.mu.Lock()
+			s.heartbeats++
+			if !s.stalled[ws] {
+				hb, _ := proto.Marshal(&message.PushFrame{PayloadType: enums.PayloadTypeHeartbeat})
+				ws.WriteMessage(websocket.BinaryMessage, hb)
+			}
+			s.mu.Unlock()
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package protodump
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	FailedToWriteCatalogError = "FailedToWriteCatalogError: %v"
+
+	maxExamples = 3
+	flushEvery  = 1000
+)
+
+var (
+	catalogs     = make(map[string]*Catalog)
+	catalogMutex sync.Mutex
+	unsafeName   = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+)
+
+// Catalog 按方法名汇总未知消息，每个方法写入 dir 下的一个 JSON 文件。
+// 文件已存在时在其基础上继续累计，多次采集的样本数会叠加
+type Catalog struct {
+	dir     string
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// Entry 是一个方法的目录文件内容
+type Entry struct {
+	Method    string
+	Samples   uint64
+	Invalid   uint64 // 不是合法 wire 格式的样本数
+	FirstSeen time.Time
+	LastSeen  time.Time
+	Fields    []FieldStat
+	Examples  []Example // 最近的几个样本
+}
+
+// FieldStat 统计一个字段路径，如 "2.1" 表示字段 2 中嵌套消息的字段 1
+type FieldStat struct {
+	Path     string
+	Samples  uint64            // 出现过该字段的样本数
+	Kinds    map[string]uint64 // 按 Field.Kind 统计，同一字段出现多种类型时需人工判断
+	Repeated bool              // 同一条消息中出现过多次
+	Example  string
+}
+
+type Example struct {
+	ReceivedAt time.Time
+	Size       int
+	Dump       []string
+	Raw        []byte
+}
+
+type entry struct {
+	Entry
+	fields map[string]*FieldStat
+	dirty  bool
+}
+
+// OpenCatalog 返回 dir 对应的目录，同一进程内多个直播间共享同一个实例
+func OpenCatalog(dir string) *Catalog {
+	catalogMutex.Lock()
+	defer catalogMutex.Unlock()
+	dir = filepath.Clean(dir)
+	if c, ok := catalogs[dir]; ok {
+		return c
+	}
+	c := &Catalog{dir: dir, entries: make(map[string]*entry)}
+	catalogs[dir] = c
+	return c
+}
+
+func (c *Catalog) Dir() string {
+	return c.dir
+}
+
+// Add 记录一个样本并返回解析结果，payload 不是合法 wire 格式时返回错误。
+// 样本数为 2 的幂或每 flushEvery 条时写入文件，其余由 Flush 写入
+func (c *Catalog) Add(method string, payload []byte, receivedAt time.Time) ([]Field, error) {
+	fields, err := Parse(payload)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e := c.entry(method)
+	e.Samples++
+	if e.FirstSeen.IsZero() {
+		e.FirstSeen = receivedAt
+	}
+	e.LastSeen = receivedAt
+	e.dirty = true
+	if err != nil {
+		e.Invalid++
+	} else {
+		seen := make(map[string]bool)
+		e.count("", fields, seen)
+		e.Examples = append(e.Examples, Example{
+			ReceivedAt: receivedAt,
+			Size:       len(payload),
+			Dump:       strings.Split(strings.TrimSuffix(Format(fields, "  "), "\n"), "\n"),
+			Raw:        payload,
+		})
+		if len(e.Examples) > maxExamples {
+			e.Examples = e.Examples[len(e.Examples)-maxExamples:]
+		}
+	}
+	if e.Samples&(e.Samples-1) == 0 || e.Samples%flushEvery == 0 {
+		if werr := c.write(e); werr != nil && err == nil {
+			err = werr
+		}
+	}
+	return fields, err
+}
+
+// Flush 写入所有尚未落盘的方法
+func (c *Catalog) Flush() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var first error
+	for _, e := range c.entries {
+		if !e.dirty {
+			continue
+		}
+		if err := c.write(e); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Path 返回方法对应的目录文件路径
+func (c *Catalog) Path(method string) string {
+	return filepath.Join(c.dir, unsafeName.ReplaceAllString(method, "_")+".json")
+}
+
+// entry 返回内存中的记录，第一次出现时尝试从已有文件恢复
+func (c *Catalog) entry(method string) *entry {
+	if e, ok := c.entries[method]; ok {
+		return e
+	}
+	e := &entry{Entry: Entry{Method: method}, fields: make(map[string]*FieldStat)}
+	if data, err := os.ReadFile(c.Path(method)); err == nil && json.Unmarshal(data, &e.Entry) == nil {
+		for i := range e.Fields {
+			stat := e.Fields[i]
+			e.fields[stat.Path] = &stat
+		}
+	}
+	e.Method = method
+	c.entries[method] = e
+	return e
+}
+
+func (e *entry) count(parent string, fields []Field, seen map[string]bool) {
+	repeated := make(map[string]bool)
+	for _, field := range fields {
+		path := strconv.Itoa(int(field.Number))
+		if parent != "" {
+			path = parent + "." + path
+		}
+		stat, ok := e.fields[path]
+		if !ok {
+			stat = &FieldStat{Path: path, Kinds: make(map[string]uint64)}
+			e.fields[path] = stat
+		}
+		if !seen[path] {
+			seen[path] = true
+			stat.Samples++
+		}
+		if repeated[path] {
+			stat.Repeated = true
+		}
+		repeated[path] = true
+		stat.Kinds[field.Kind()]++
+		if field.Message != nil {
+			e.count(path, field.Message, seen)
+		} else if value := field.Value(); stat.Example == "" || value != `""` {
+			// 保留最近一个非空的值
+			stat.Example = value
+		}
+	}
+}
+
+func (c *Catalog) write(e *entry) error {
+	e.Fields = e.Fields[:0]
+	for _, stat := range e.fields {
+		e.Fields = append(e.Fields, *stat)
+	}
+	sort.Slice(e.Fields, func(i, j int) bool {
+		return lessPath(e.Fields[i].Path, e.Fields[j].Path)
+	})
+	data, err := json.MarshalIndent(e.Entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf(FailedToWriteCatalogError, err)
+	}
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return fmt.Errorf(FailedToWriteCatalogError, err)
+	}
+	if err := os.WriteFile(c.Path(e.Method), data, 0644); err != nil {
+		return fmt.Errorf(FailedToWriteCatalogError, err)
+	}
+	e.dirty = false
+	return nil
+}
+
+// lessPath 按数字逐段比较，保证 "2" 排在 "10" 之前
+func lessPath(a, b string) bool {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		x, _ := strconv.Atoi(as[i])
+		y, _ := strconv.Atoi(bs[i])
+		if x != y {
+			return x < y
+		}
+	}
+	return len(as) < len(bs)
+}
@@ -0,0 +1,186 @@
+// Package protodump 在没有 .proto 定义的情况下按 wire 格式解析 protobuf 数据，
+// 用于分析抖音新增的消息类型，输出与 protoc --decode_raw 类似。
+package protodump
+
+import (
+	"errors"
+	"fmt"
+	"google.golang.org/protobuf/encoding/protowire"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const maxDepth = 16
+
+var InvalidWireData = errors.New("invalid protobuf wire data")
+
+// Field 是解析出的一个字段。bytes 类型的字段会依次尝试按 UTF-8 字符串、嵌套消息解析，
+// 都不符合时保留原始字节
+type Field struct {
+	Number  protowire.Number
+	Type    protowire.Type
+	Varint  uint64  // VarintType、Fixed32Type、Fixed64Type 的原始值
+	Bytes   []byte  // BytesType 的原始数据
+	Message []Field // 非空时 Bytes 被猜测为嵌套消息
+	Text    string
+	IsText  bool // Bytes 为可打印的 UTF-8 字符串
+}
+
+// Parse 解析一段 protobuf 数据，数据不完整或不是合法的 wire 格式时返回 InvalidWireData
+func Parse(data []byte) ([]Field, error) {
+	return parse(data, 0)
+}
+
+func parse(data []byte, depth int) ([]Field, error) {
+	var fields []Field
+	for len(data) > 0 {
+		number, typ, n := protowire.ConsumeTag(data)
+		if n < 0 || number < 1 {
+			return nil, InvalidWireData
+		}
+		data = data[n:]
+		field := Field{Number: number, Type: typ}
+		switch typ {
+		case protowire.VarintType:
+			field.Varint, n = protowire.ConsumeVarint(data)
+		case protowire.Fixed32Type:
+			var v uint32
+			v, n = protowire.ConsumeFixed32(data)
+			field.Varint = uint64(v)
+		case protowire.Fixed64Type:
+			field.Varint, n = protowire.ConsumeFixed64(data)
+		case protowire.BytesType:
+			field.Bytes, n = protowire.ConsumeBytes(data)
+			if n >= 0 {
+				field.guess(depth)
+			}
+		default:
+			// group 已废弃，抖音的消息中不会出现
+			return nil, InvalidWireData
+		}
+		if n < 0 {
+			return nil, InvalidWireData
+		}
+		data = data[n:]
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// guess 判断 bytes 字段更像字符串还是嵌套消息。可打印文本优先，
+// 因为短字符串常常恰好也是合法的 wire 数据
+func (f *Field) guess(depth int) {
+	if len(f.Bytes) == 0 {
+		f.IsText = true
+		return
+	}
+	if isText(f.Bytes) {
+		f.Text, f.IsText = string(f.Bytes), true
+		return
+	}
+	if depth >= maxDepth {
+		return
+	}
+	if message, err := parse(f.Bytes, depth+1); err == nil {
+		f.Message = message
+	}
+}
+
+func isText(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// Kind 返回字段的类型描述，Catalog 按它统计同一字段出现过的类型
+func (f Field) Kind() string {
+	switch f.Type {
+	case protowire.VarintType:
+		return "varint"
+	case protowire.Fixed32Type:
+		return "fixed32"
+	case protowire.Fixed64Type:
+		return "fixed64"
+	}
+	switch {
+	case f.IsText:
+		return "string"
+	case f.Message != nil:
+		return "message"
+	}
+	return "bytes"
+}
+
+// Value 返回标量字段的单行表示，嵌套消息返回 "{...}"
+func (f Field) Value() string {
+	switch f.Type {
+	case protowire.VarintType:
+		// 负的 int32 / int64 编码为 10 字节的大数，附上有符号值
+		if int64(f.Varint) < 0 {
+			return fmt.Sprintf("%d (int %d)", f.Varint, int64(f.Varint))
+		}
+		return strconv.FormatUint(f.Varint, 10)
+	case protowire.Fixed32Type:
+		return fmt.Sprintf("0x%08x (float %g)", f.Varint, math.Float32frombits(uint32(f.Varint)))
+	case protowire.Fixed64Type:
+		return fmt.Sprintf("0x%016x (double %g)", f.Varint, math.Float64frombits(f.Varint))
+	}
+	switch {
+	case f.IsText:
+		return strconv.Quote(f.Text)
+	case f.Message != nil:
+		return "{...}"
+	}
+	return fmt.Sprintf("<% x>", f.Bytes)
+}
+
+// Format 按 protoc --decode_raw 的风格输出多行文本，indent 为每层缩进
+func Format(fields []Field, indent string) string {
+	var b strings.Builder
+	format(&b, fields, indent, 0)
+	return b.String()
+}
+
+func format(b *strings.Builder, fields []Field, indent string, depth int) {
+	prefix := strings.Repeat(indent, depth)
+	for _, field := range fields {
+		if field.Message != nil {
+			fmt.Fprintf(b, "%s%d {\n", prefix, field.Number)
+			format(b, field.Message, indent, depth+1)
+			fmt.Fprintf(b, "%s}\n", prefix)
+			continue
+		}
+		fmt.Fprintf(b, "%s%d: %s\n", prefix, field.Number, field.Value())
+	}
+}
+
+// Compact 输出单行文本，用于日志
+func Compact(fields []Field) string {
+	var b strings.Builder
+	compact(&b, fields)
+	return b.String()
+}
+
+func compact(b *strings.Builder, fields []Field) {
+	for i, field := range fields {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if field.Message != nil {
+			fmt.Fprintf(b, "%d {", field.Number)
+			compact(b, field.Message)
+			b.WriteByte('}')
+			continue
+		}
+		fmt.Fprintf(b, "%d: %s", field.Number, field.Value())
+	}
+}
@@ -0,0 +1,87 @@
+package protodump
+
+import (
+	"douyinLiveCollectors/backend/common/message"
+	"encoding/json"
+	"google.golang.org/protobuf/proto"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseGuessesStringsAndMessages(t *testing.T) {
+	payload, err := proto.Marshal(&message.ChatMessage{
+		Common:  &message.Common{Method: "WebcastChatMessage", MsgId: 42},
+		User:    &message.User{Id: 7, NickName: "观众"},
+		Content: "你好",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := Parse(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dump := Format(fields, "  ")
+	for _, want := range []string{"1 {\n  1: \"WebcastChatMessage\"\n  2: 42\n", "2 {\n  1: 7\n  3: \"观众\"\n", "3: \"你好\"\n"} {
+		if !strings.Contains(dump, want) {
+			t.Fatalf("dump missing %q:\n%s", want, dump)
+		}
+	}
+	if _, err := Parse([]byte{0x0a, 0x05, 'a'}); err != InvalidWireData {
+		t.Fatalf("truncated payload: %v", err)
+	}
+}
+
+func TestCatalogAccumulatesAcrossRuns(t *testing.T) {
+	dir := t.TempDir()
+	const method = "WebcastNewMessage"
+	now := time.Now()
+	for _, payload := range [][]byte{
+		protoBytes(t, &message.Common{Method: method, MsgId: 1}),
+		protoBytes(t, &message.Common{Method: method, MsgId: 2, RoomId: 3}),
+		{0xff},
+	} {
+		OpenCatalog(dir).Add(method, payload, now)
+	}
+	if err := OpenCatalog(dir).Flush(); err != nil {
+		t.Fatal(err)
+	}
+
+	// 模拟重启后继续写入同一个目录
+	catalogMutex.Lock()
+	delete(catalogs, dir)
+	catalogMutex.Unlock()
+	catalog := OpenCatalog(dir)
+	if _, err := catalog.Add(method, protoBytes(t, &message.Common{MsgId: 4}), now); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(catalog.Path(method))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		t.Fatal(err)
+	}
+	if entry.Samples != 4 || entry.Invalid != 1 || len(entry.Examples) != 3 {
+		t.Fatalf("samples = %d, invalid = %d, examples = %d", entry.Samples, entry.Invalid, len(entry.Examples))
+	}
+	paths := make(map[string]uint64)
+	for _, field := range entry.Fields {
+		paths[field.Path] = field.Samples
+	}
+	if paths["1"] != 2 || paths["2"] != 3 || paths["3"] != 1 {
+		t.Fatalf("fields = %+v", entry.Fields)
+	}
+}
+
+func protoBytes(t *testing.T, m proto.Message) []byte {
+	data, err := proto.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
@@ -0,0 +1,177 @@
+// Package replay 将 archive 录制的原始帧重新送入 handler，得到与实时采集相同的事件。
+package replay
+
+import (
+	"context"
+	"douyinLiveCollectors/backend/common/archive"
+	"douyinLiveCollectors/backend/common/handler"
+	"douyinLiveCollectors/backend/common/log"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	FailedToListRecordingsError = "FailedToListRecordingsError: %v"
+)
+
+// Options 控制回放速度与起点
+type Options struct {
+	Speed   float64       // 1 为实时，>1 为加速，<= 0 为不等待、尽快回放
+	From    time.Time     // 跳过收到时间早于 From 的帧，零值表示从头开始
+	Offset  time.Duration // From 为零值时，从会话开始后 Offset 处开始
+	Handler handler.Options
+}
+
+// Player 按录制顺序回放一个归档文件。Out 的语义与 LiveViewer.Out 相同，回放结束或 ctx 取消后关闭
+type Player struct {
+	reader  *archive.Reader
+	opts    Options
+	logger  *log.DefaultLogger
+	handler *handler.Handler
+	frames  int
+	Out     chan handler.Event
+}
+
+// discardConn 吞掉回放时产生的 ack
+type discardConn struct{}
+
+func (discardConn) WriteMessage(int, []byte) error {
+	return nil
+}
+
+func New(path string, opts Options) (*Player, error) {
+	reader, err := archive.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	header := reader.Header()
+	// 回放不需要保护连接，默认等待消费者而不是丢弃
+	if opts.Handler.Policy == "" {
+		opts.Handler.Policy = handler.PolicyBlock
+	}
+	if opts.From.IsZero() && opts.Offset > 0 {
+		opts.From = header.StartedAt.Add(opts.Offset)
+	}
+	p := &Player{
+		reader: reader,
+		opts:   opts,
+		logger: log.NewRoomLogger(fmt.Sprintf("replay-%d", header.LiveId)),
+		Out:    make(chan handler.Event),
+	}
+	p.handler = handler.NewHandler(p.logger, p.Out, opts.Handler)
+	return p, nil
+}
+
+func (p *Player) Header() archive.Header {
+	return p.reader.Header()
+}
+
+// Registry 返回回放使用的解码器注册表，可与实时采集注册相同的解码器
+func (p *Player) Registry() *handler.Registry {
+	return p.handler.Registry()
+}
+
+// DriftReport 返回回放过程中的协议变化统计，可用于检查旧会话在当前 douyin.proto 下的解析情况
+func (p *Player) DriftReport() handler.DriftReport {
+	return p.handler.DriftReport()
+}
+
+// Frames 返回已送入 handler 的帧数
+func (p *Player) Frames() int {
+	return p.frames
+}
+
+// Run 阻塞直到回放结束；归档末尾不完整时视为正常结束。
+// ctx 取消时尚未送出的事件会被丢弃
+func (p *Player) Run(ctx context.Context) (err error) {
+	defer func() {
+		if err != nil {
+			p.handler.Close()
+			p.handler.Wait()
+		} else {
+			p.handler.Wait()
+			p.handler.Close()
+		}
+		p.reader.Close()
+		p.logger.Info("Replayed %d frames", p.frames)
+		p.logger.Close()
+		close(p.Out)
+	}()
+
+	var first time.Time
+	var startedAt time.Time
+	for {
+		frame, err := p.reader.Next()
+		if err == io.EOF || errors.Is(err, archive.Truncated) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if frame.ReceivedAt.Before(p.opts.From) {
+			continue
+		}
+
+		if p.opts.Speed > 0 {
+			if first.IsZero() {
+				first, startedAt = frame.ReceivedAt, time.Now()
+			}
+			due := startedAt.Add(time.Duration(float64(frame.ReceivedAt.Sub(first)) / p.opts.Speed))
+			select {
+			case <-time.After(time.Until(due)):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		} else if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		p.handler.Handle(discardConn{}, frame.Data)
+		p.frames++
+	}
+}
+
+// Recording 是目录中的一个归档文件
+type Recording struct {
+	Path   string
+	Size   int64
+	Header archive.Header
+}
+
+// List 返回 dir 下所有可读取的归档，按开始时间倒序
+func List(dir string) ([]Recording, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf(FailedToListRecordingsError, err)
+	}
+	var recordings []Recording
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), archive.Extension) {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		reader, err := archive.Open(path)
+		if err != nil {
+			continue
+		}
+		recording := Recording{Path: path, Header: reader.Header()}
+		reader.Close()
+		if info, err := entry.Info(); err == nil {
+			recording.Size = info.Size()
+		}
+		recordings = append(recordings, recording)
+	}
+	sort.Slice(recordings, func(i, j int) bool {
+		return recordings[i].Header.StartedAt.After(recordings[j].Header.StartedAt)
+	})
+	return recordings, nil
+}
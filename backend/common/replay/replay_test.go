@@ -0,0 +1,94 @@
+package replay
+
+import (
+	"context"
+	"douyinLiveCollectors/backend/common/archive"
+	"douyinLiveCollectors/backend/common/handler"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "replay-test")
+	if err != nil {
+		panic(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// record 写入三帧，相邻两帧间隔 100ms
+func record(t *testing.T) (string, time.Time) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "session"+archive.Extension)
+	start := time.Now().Truncate(time.Millisecond)
+	w, err := archive.Create(path, archive.Header{LiveId: 1, RoomId: mockserver.RoomId, StartedAt: start})
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := mockserver.NewUser(1, "viewer")
+	for i, content := range []string{"one", "two", "three"} {
+		frame, err := mockserver.EncodeFrame(uint64(i+1), mockserver.NewResponse(i, mockserver.Chat(user, content)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := w.Write(start.Add(time.Duration(i)*100*time.Millisecond), frame); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path, start
+}
+
+func play(t *testing.T, path string, opts Options) []string {
+	t.Helper()
+	player, err := New(path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan error, 1)
+	go func() {
+		done <- player.Run(context.Background())
+	}()
+	var chats []string
+	for event := range player.Out {
+		if chat, ok := event.(handler.ChatEvent); ok {
+			chats = append(chats, chat.Content)
+		}
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+	return chats
+}
+
+func TestReplay(t *testing.T) {
+	path, start := record(t)
+
+	if chats := play(t, path, Options{}); len(chats) != 3 || chats[0] != "one" || chats[2] != "three" {
+		t.Fatalf("as fast as possible = %v", chats)
+	}
+	if chats := play(t, path, Options{From: start.Add(150 * time.Millisecond)}); len(chats) != 1 || chats[0] != "three" {
+		t.Fatalf("seek = %v", chats)
+	}
+
+	began := time.Now()
+	play(t, path, Options{Speed: 2})
+	if elapsed := time.Since(began); elapsed < 100*time.Millisecond {
+		t.Fatalf("2x replay of 200ms took %v", elapsed)
+	}
+
+	recordings, err := List(filepath.Dir(path))
+	if err != nil || len(recordings) != 1 || recordings[0].Header.RoomId != mockserver.RoomId {
+		t.Fatalf("List = %+v, %v", recordings, err)
+	}
+}
@@ -0,0 +1,278 @@
+package room
+
+import (
+	"context"
+	"douyinLiveCollectors/backend/common/collectors"
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/handler"
+	"douyinLiveCollectors/backend/common/tracker"
+	"douyinLiveCollectors/backend/library/time"
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	RoomAlreadyStarted = errors.New("room already started")
+	RoomNotFound       = errors.New("room not found")
+)
+
+// Status 是单个直播间采集任务的状态快照
+type Status struct {
+	LiveId     uint64
+	RoomId     string
+	State      string
+	StartedAt  string
+	UpdatedAt  string
+	Messages   uint64
+	Dropped    uint64 // 输出缓冲丢弃的消息数
+	Reconnects int
+	LastError  string
+	RecordPath string // 帧归档文件，未开启录制时为空
+}
+
+// OutputFunc 接收某个直播间解析出的消息
+type OutputFunc func(liveId uint64, event handler.Event)
+
+// LifecycleFunc 接收某个直播间的连接状态变化
+type LifecycleFunc func(event collectors.LifecycleEvent)
+
+type room struct {
+	viewer  *collectors.LiveViewer
+	cancel  context.CancelFunc
+	mu      sync.Mutex
+	status  Status
+	stopped bool
+}
+
+// Manager 管理多个并发的直播间采集任务，按 liveId 索引，每个直播间拥有独立的输出流、日志与生命周期
+type Manager struct {
+	mu          sync.RWMutex
+	rooms       map[uint64]*room
+	onOutput    OutputFunc
+	onLifecycle LifecycleFunc
+}
+
+func NewManager(onOutput OutputFunc, onLifecycle LifecycleFunc) *Manager {
+	return &Manager{
+		rooms:       make(map[uint64]*room),
+		onOutput:    onOutput,
+		onLifecycle: onLifecycle,
+	}
+}
+
+// Start 同步建立连接，失败时返回 collectors 中定义的错误且不会保留该直播间。
+// 已停止的直播间会被新的采集任务替换
+func (m *Manager) Start(liveId uint64) error {
+	m.mu.Lock()
+	if existing, ok := m.rooms[liveId]; ok && !existing.isStopped() {
+		m.mu.Unlock()
+		return RoomAlreadyStarted
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &room{
+		viewer: collectors.NewLiveViewer(liveId),
+		cancel: cancel,
+		status: Status{
+			LiveId:    liveId,
+			State:     enums.LifecycleConnecting,
+			StartedAt: time.Now(),
+			UpdatedAt: time.Now(),
+		},
+	}
+	m.rooms[liveId] = r
+	m.mu.Unlock()
+
+	go m.forwardOutput(r)
+	go m.forwardLifecycle(r)
+	if err := r.viewer.Start(ctx); err != nil {
+		cancel()
+		m.remove(r)
+		return err
+	}
+	r.setSession(r.viewer.RoomId(), r.viewer.RecordPath())
+	return nil
+}
+
+// Stop 断开连接，直播间保留为已停止状态，状态与统计仍可查询，直到调用 Remove
+func (m *Manager) Stop(liveId uint64) error {
+	m.mu.RLock()
+	r, ok := m.rooms[liveId]
+	m.mu.RUnlock()
+	if !ok {
+		return RoomNotFound
+	}
+	r.cancel()
+	return nil
+}
+
+func (m *Manager) StopAll() {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, r := range m.rooms {
+		r.cancel()
+	}
+}
+
+// Remove 清除直播间及其统计，仍在采集时先断开连接
+func (m *Manager) Remove(liveId uint64) error {
+	m.mu.Lock()
+	r, ok := m.rooms[liveId]
+	if ok {
+		delete(m.rooms, liveId)
+	}
+	m.mu.Unlock()
+	if !ok {
+		return RoomNotFound
+	}
+	r.cancel()
+	return nil
+}
+
+func (m *Manager) Status(liveId uint64) (Status, error) {
+	m.mu.RLock()
+	r, ok := m.rooms[liveId]
+	m.mu.RUnlock()
+	if !ok {
+		return Status{}, RoomNotFound
+	}
+	return r.snapshot(), nil
+}
+
+// DriftReport 返回直播间的协议变化统计
+func (m *Manager) DriftReport(liveId uint64) (handler.DriftReport, error) {
+	viewer, err := m.viewer(liveId)
+	if err != nil {
+		return handler.DriftReport{}, err
+	}
+	return viewer.DriftReport(), nil
+}
+
+// ProductTimeline 返回直播间的商品讲解时间线
+func (m *Manager) ProductTimeline(liveId uint64) ([]tracker.ProductWindow, error) {
+	viewer, err := m.viewer(liveId)
+	if err != nil {
+		return nil, err
+	}
+	return viewer.Products().Windows(), nil
+}
+
+// MatchTimeline 返回直播间当前的比分与比分变化历史
+func (m *Manager) MatchTimeline(liveId uint64) (tracker.MatchTimeline, error) {
+	viewer, err := m.viewer(liveId)
+	if err != nil {
+		return tracker.MatchTimeline{}, err
+	}
+	return viewer.Scoreboard().Timeline(), nil
+}
+
+// FanTicketSeries 返回直播间本场音浪的时间序列
+func (m *Manager) FanTicketSeries(liveId uint64) ([]tracker.FanTicketPoint, error) {
+	viewer, err := m.viewer(liveId)
+	if err != nil {
+		return nil, err
+	}
+	return viewer.FanTickets().Points(), nil
+}
+
+// NoticeScenes 返回直播间按场景归类的系统消息统计
+func (m *Manager) NoticeScenes(liveId uint64) ([]tracker.SceneStat, error) {
+	viewer, err := m.viewer(liveId)
+	if err != nil {
+		return nil, err
+	}
+	return viewer.Notices().Scenes(), nil
+}
+
+func (m *Manager) viewer(liveId uint64) (*collectors.LiveViewer, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	r, ok := m.rooms[liveId]
+	if !ok {
+		return nil, RoomNotFound
+	}
+	return r.viewer, nil
+}
+
+// List 按 liveId 升序返回所有直播间的状态
+func (m *Manager) List() []Status {
+	m.mu.RLock()
+	list := make([]Status, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		list = append(list, r.snapshot())
+	}
+	m.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].LiveId < list[j].LiveId
+	})
+	return list
+}
+
+func (m *Manager) forwardOutput(r *room) {
+	liveId := r.viewer.LiveId()
+	for event := range r.viewer.Out {
+		r.mu.Lock()
+		r.status.Messages++
+		r.mu.Unlock()
+		if m.onOutput != nil {
+			m.onOutput(liveId, event)
+		}
+	}
+}
+
+func (m *Manager) forwardLifecycle(r *room) {
+	for event := range r.viewer.Lifecycle {
+		r.apply(event)
+		if m.onLifecycle != nil {
+			m.onLifecycle(event)
+		}
+	}
+	// Lifecycle 关闭说明采集已停止（下播、重连失败或 Stop），保留直播间供查询
+	r.apply(collectors.LifecycleEvent{Type: enums.LifecycleStopped})
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+	r.cancel()
+}
+
+func (m *Manager) remove(r *room) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.rooms[r.viewer.LiveId()] == r {
+		delete(m.rooms, r.viewer.LiveId())
+	}
+}
+
+func (r *room) apply(event collectors.LifecycleEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.State = event.Type
+	r.status.UpdatedAt = time.Now()
+	if event.Type == enums.LifecycleReconnected {
+		r.status.Reconnects++
+	}
+	if event.Error != "" {
+		r.status.LastError = event.Error
+	}
+}
+
+func (r *room) isStopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopped
+}
+
+func (r *room) setSession(roomId string, recordPath string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status.RoomId = roomId
+	r.status.RecordPath = recordPath
+}
+
+func (r *room) snapshot() Status {
+	r.mu.Lock()
+	status := r.status
+	r.mu.Unlock()
+	status.Dropped = r.viewer.OutputStats().Dropped
+	return status
+}
@@ -0,0 +1,116 @@
+package tracker
+
+import (
+	"douyinLiveCollectors/backend/common/handler"
+	"sort"
+	"sync"
+	"time"
+)
+
+// FanTicketPoint 是音浪时间序列中的一个点
+type FanTicketPoint struct {
+	Time  time.Time
+	Count uint64
+}
+
+// FanTicketSeries 记录本场音浪的变化，作为主播收入的近似值。只在数值变化时追加一个点
+type FanTicketSeries struct {
+	mu     sync.Mutex
+	points []FanTicketPoint
+}
+
+func NewFanTicketSeries() *FanTicketSeries {
+	return &FanTicketSeries{}
+}
+
+// Observe 可直接作为 handler.CallbackFunc 注册
+func (s *FanTicketSeries) Observe(event handler.Event) {
+	e, ok := event.(handler.FanTicketEvent)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if n := len(s.points); n > 0 && s.points[n-1].Count == e.Count && !e.ForceUpdate {
+		return
+	}
+	s.points = append(s.points, FanTicketPoint{Time: e.Time(), Count: e.Count})
+}
+
+func (s *FanTicketSeries) Points() []FanTicketPoint {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]FanTicketPoint(nil), s.points...)
+}
+
+// Increase 返回 [from, to) 内音浪的增量，以区间前最后一个点为起点
+func (s *FanTicketSeries) Increase(from, to time.Time) uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var start, end uint64
+	for _, point := range s.points {
+		if point.Time.Before(from) {
+			start = point.Count
+		}
+		if point.Time.Before(to) {
+			end = point.Count
+		}
+	}
+	if end < start {
+		return 0
+	}
+	return end - start
+}
+
+// SceneStat 是某个场景的通用文本消息统计
+type SceneStat struct {
+	Scene    string
+	Count    uint64
+	LastText string
+	LastSeen time.Time
+}
+
+// Notices 按 scene 归类通用文本消息
+type Notices struct {
+	mu     sync.Mutex
+	scenes map[string]*SceneStat
+}
+
+func NewNotices() *Notices {
+	return &Notices{scenes: make(map[string]*SceneStat)}
+}
+
+// Observe 可直接作为 handler.CallbackFunc 注册
+func (n *Notices) Observe(event handler.Event) {
+	e, ok := event.(handler.CommonTextEvent)
+	if !ok {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	stat, ok := n.scenes[e.Scene]
+	if !ok {
+		stat = &SceneStat{Scene: e.Scene}
+		n.scenes[e.Scene] = stat
+	}
+	stat.Count++
+	stat.LastText = e.Text
+	stat.LastSeen = e.Time()
+}
+
+// Scenes 按消息数从多到少返回各场景的统计
+func (n *Notices) Scenes() []SceneStat {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	scenes := make([]SceneStat, 0, len(n.scenes))
+	for _, stat := range n.scenes {
+		scenes = append(scenes, *stat)
+	}
+	sort.Slice(scenes, func(i, j int) bool {
+		if scenes[i].Count != scenes[j].Count {
+			return scenes[i].Count > scenes[j].Count
+		}
+		return scenes[i].Scene < scenes[j].Scene
+	})
+	return scenes
+}
@@ -0,0 +1,46 @@
+package tracker
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/handler"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"testing"
+	"time"
+)
+
+func TestFanTicketSeriesAndNotices(t *testing.T) {
+	start := time.Now()
+	fanTicket := func(offset time.Duration, count uint64) handler.Event {
+		common := mockserver.NewCommon(enums.WebcastUpdateFanTicketMessage)
+		common.CreateTime = uint64(start.Add(offset).UnixMilli())
+		return decode(t, mockserver.NewMessage(enums.WebcastUpdateFanTicketMessage, &message.UpdateFanTicketMessage{
+			Common:             common,
+			RoomFanTicketCount: count,
+		}))
+	}
+	series := NewFanTicketSeries()
+	for i, count := range []uint64{100, 100, 150, 400} {
+		series.Observe(fanTicket(time.Duration(i)*time.Minute, count))
+	}
+	if points := series.Points(); len(points) != 3 || points[2].Count != 400 {
+		t.Fatalf("points = %+v", points)
+	}
+	if increase := series.Increase(start.Add(90*time.Second), start.Add(4*time.Minute)); increase != 300 {
+		t.Fatalf("increase = %d", increase)
+	}
+
+	notices := NewNotices()
+	for _, scene := range []string{"follow_guide", "room_notice", "room_notice"} {
+		common := mockserver.NewCommon(enums.WebcastCommonTextMessage)
+		common.Describe = scene + " text"
+		notices.Observe(decode(t, mockserver.NewMessage(enums.WebcastCommonTextMessage, &message.CommonTextMessage{
+			Common: common,
+			Scene:  scene,
+		})))
+	}
+	scenes := notices.Scenes()
+	if len(scenes) != 2 || scenes[0].Scene != "room_notice" || scenes[0].Count != 2 || scenes[0].LastText != "room_notice text" {
+		t.Fatalf("scenes = %+v", scenes)
+	}
+}
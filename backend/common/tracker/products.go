@@ -0,0 +1,96 @@
+// Package tracker 汇总单个采集会话中的事件。各类 tracker 通过 Registry.On 挂载，
+// 回调在 Handler 的输出协程中按序执行，读取方法可在其他协程中并发调用。
+package tracker
+
+import (
+	"douyinLiveCollectors/backend/common/handler"
+	"sync"
+	"time"
+)
+
+// ProductWindow 是一段商品讲解，以及讲解期间的聊天与礼物数
+type ProductWindow struct {
+	PromotionId int64
+	Start       time.Time
+	End         time.Time // 零值表示仍在讲解
+	Chats       uint64
+	Gifts       uint64
+}
+
+// ProductTimeline 记录每个 promotionId 的讲解时间段。讲解中的商品以最近一次 LiveShopping 消息为准，
+// promotionId 为 0 时结束讲解；ProductChange 中 explainType 非 0 的商品同样视为开始讲解
+type ProductTimeline struct {
+	mu      sync.Mutex
+	windows []ProductWindow
+}
+
+func NewProductTimeline() *ProductTimeline {
+	return &ProductTimeline{}
+}
+
+// Observe 可直接作为 handler.CallbackFunc 注册
+func (t *ProductTimeline) Observe(event handler.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	switch e := event.(type) {
+	case handler.LiveShoppingEvent:
+		t.explain(e.PromotionId, e.Time())
+	case handler.ProductChangeEvent:
+		if explaining := e.Explaining(); explaining != 0 {
+			t.explain(explaining, e.Time())
+		}
+	case handler.ChatEvent:
+		if current := t.current(); current != nil {
+			current.Chats++
+		}
+	case handler.GiftComboEvent:
+		// 按合并后的礼物数统计，连击中的每一条 GiftEvent 不重复计数
+		if current := t.current(); current != nil {
+			current.Gifts += e.Count
+		}
+	}
+}
+
+func (t *ProductTimeline) current() *ProductWindow {
+	if len(t.windows) == 0 || !t.windows[len(t.windows)-1].End.IsZero() {
+		return nil
+	}
+	return &t.windows[len(t.windows)-1]
+}
+
+func (t *ProductTimeline) explain(promotionId int64, at time.Time) {
+	current := t.current()
+	if current != nil && current.PromotionId == promotionId {
+		return
+	}
+	if current != nil {
+		current.End = at
+	}
+	if promotionId != 0 {
+		t.windows = append(t.windows, ProductWindow{PromotionId: promotionId, Start: at})
+	}
+}
+
+// Windows 按开始时间返回所有讲解时间段
+func (t *ProductTimeline) Windows() []ProductWindow {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]ProductWindow(nil), t.windows...)
+}
+
+// At 返回 at 时刻正在讲解的商品
+func (t *ProductTimeline) At(at time.Time) (ProductWindow, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := len(t.windows) - 1; i >= 0; i-- {
+		w := t.windows[i]
+		if at.Before(w.Start) {
+			continue
+		}
+		if w.End.IsZero() || at.Before(w.End) {
+			return w, true
+		}
+		break
+	}
+	return ProductWindow{}, false
+}
@@ -0,0 +1,65 @@
+package tracker
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/handler"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"testing"
+	"time"
+)
+
+func decode(t *testing.T, msg *message.Message) handler.Event {
+	event, _, err := handler.NewRegistry().Decode(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return event
+}
+
+func TestProductTimeline(t *testing.T) {
+	user := mockserver.NewUser(1, "viewer")
+	start := time.Now()
+	at := func(offset time.Duration, method string) *message.Common {
+		common := mockserver.NewCommon(method)
+		common.CreateTime = uint64(start.Add(offset).UnixMilli())
+		return common
+	}
+	shopping := func(offset time.Duration, promotionId int64) handler.Event {
+		return decode(t, mockserver.NewMessage(enums.WebcastLiveShoppingMessage, &message.LiveShoppingMessage{
+			Common:      at(offset, enums.WebcastLiveShoppingMessage),
+			PromotionId: promotionId,
+		}))
+	}
+
+	timeline := NewProductTimeline()
+	timeline.Observe(decode(t, mockserver.Chat(user, "before")))
+	timeline.Observe(shopping(time.Minute, 100))
+	timeline.Observe(decode(t, mockserver.Chat(user, "during")))
+	timeline.Observe(decode(t, mockserver.Gift(user, "小心心", 3)))
+	timeline.Observe(handler.GiftComboEvent{EventMeta: handler.EventMeta{Method: handler.GiftComboMethod}, Count: 3})
+	timeline.Observe(decode(t, mockserver.NewMessage(enums.WebcastProductChangeMessage, &message.ProductChangeMessage{
+		Common:                at(2*time.Minute, enums.WebcastProductChangeMessage),
+		UpdateProductInfoList: []*message.ProductInfo{{PromotionId: 200, ExplainType: 1}},
+	})))
+	timeline.Observe(decode(t, mockserver.Chat(user, "second")))
+	timeline.Observe(shopping(3*time.Minute, 0))
+	timeline.Observe(decode(t, mockserver.Chat(user, "after")))
+
+	windows := timeline.Windows()
+	if len(windows) != 2 {
+		t.Fatalf("windows = %+v", windows)
+	}
+	if w := windows[0]; w.PromotionId != 100 || w.Chats != 1 || w.Gifts != 3 || !w.End.Equal(windows[1].Start) {
+		t.Fatalf("first window = %+v", w)
+	}
+	if w := windows[1]; w.PromotionId != 200 || w.Chats != 1 || w.End.IsZero() {
+		t.Fatalf("second window = %+v", w)
+	}
+	if w, ok := timeline.At(start.Add(90 * time.Second)); !ok || w.PromotionId != 100 {
+		t.Fatalf("At = %+v, %v", w, ok)
+	}
+	if _, ok := timeline.At(start.Add(4 * time.Minute)); ok {
+		t.Fatal("At after the last window")
+	}
+}
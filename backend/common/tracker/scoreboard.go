@@ -0,0 +1,94 @@
+package tracker
+
+import (
+	"douyinLiveCollectors/backend/common/handler"
+	"sync"
+	"time"
+)
+
+// MatchState 是某一时刻的比分
+type MatchState struct {
+	Time        time.Time
+	LeftName    string
+	RightName   string
+	LeftGoal    string
+	RightGoal   string
+	LeftScore   uint64
+	RightScore  uint64
+	Stage       uint32
+	FinalStage  uint32
+	MatchStatus uint32
+	Version     uint64
+}
+
+// MatchTimeline 是当前比分与比分变化的历史，History 的最后一项即比分最近一次变化
+type MatchTimeline struct {
+	Current MatchState
+	History []MatchState
+}
+
+// Scoreboard 保存体育赛事直播间的比分。版本号小于当前的更新会被忽略，
+// 比分、阶段或比赛状态变化时追加一条历史，仅队名等信息变化时只更新当前状态
+type Scoreboard struct {
+	mu      sync.Mutex
+	started bool
+	current MatchState
+	history []MatchState
+}
+
+func NewScoreboard() *Scoreboard {
+	return &Scoreboard{}
+}
+
+// Observe 可直接作为 handler.CallbackFunc 注册
+func (s *Scoreboard) Observe(event handler.Event) {
+	e, ok := event.(handler.MatchScoreEvent)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.started && e.Version != 0 && e.Version < s.current.Version {
+		return
+	}
+	state := MatchState{
+		Time:        e.Time(),
+		LeftName:    e.Left.Name,
+		RightName:   e.Right.Name,
+		LeftGoal:    e.Left.Goal,
+		RightGoal:   e.Right.Goal,
+		LeftScore:   e.Left.Score,
+		RightScore:  e.Right.Score,
+		Stage:       e.Stage,
+		FinalStage:  e.FinalStage,
+		MatchStatus: e.MatchStatus,
+		Version:     e.Version,
+	}
+	if !s.started || s.current.changed(state) {
+		s.history = append(s.history, state)
+	}
+	s.started = true
+	s.current = state
+}
+
+func (m MatchState) changed(next MatchState) bool {
+	return m.LeftGoal != next.LeftGoal || m.RightGoal != next.RightGoal ||
+		m.LeftScore != next.LeftScore || m.RightScore != next.RightScore ||
+		m.Stage != next.Stage || m.MatchStatus != next.MatchStatus
+}
+
+// Current 返回当前比分，尚未收到比分消息时 ok 为 false
+func (s *Scoreboard) Current() (state MatchState, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.current, s.started
+}
+
+func (s *Scoreboard) Timeline() MatchTimeline {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return MatchTimeline{
+		Current: s.current,
+		History: append([]MatchState(nil), s.history...),
+	}
+}
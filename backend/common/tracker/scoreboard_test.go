@@ -0,0 +1,50 @@
+package tracker
+
+import (
+	"douyinLiveCollectors/backend/common/enums"
+	"douyinLiveCollectors/backend/common/message"
+	"douyinLiveCollectors/backend/common/mockserver"
+	"testing"
+)
+
+func TestScoreboardHistory(t *testing.T) {
+	score := func(version, left, right uint64, stage uint32) *message.Message {
+		return mockserver.NewMessage(enums.WebcastMatchAgainstScoreMessage, &message.MatchAgainstScoreMessage{
+			Common: mockserver.NewCommon(enums.WebcastMatchAgainstScoreMessage),
+			Against: &message.Against{
+				LeftName:         "主队",
+				RightName:        "客队",
+				LeftGoalInt:      left,
+				RightGoalInt:     right,
+				CurrentGoalStage: stage,
+				Version:          version,
+				LeftLogo:         &message.Image{UrlListList: []string{"https://example.com/home.png"}},
+			},
+		})
+	}
+
+	board := NewScoreboard()
+	if _, ok := board.Current(); ok {
+		t.Fatal("empty scoreboard has a score")
+	}
+	for _, msg := range []*message.Message{
+		score(1, 0, 0, 1),
+		score(2, 0, 0, 1), // 比分未变化
+		score(3, 1, 0, 1),
+		score(2, 0, 0, 1), // 旧版本
+		score(4, 1, 0, 2),
+	} {
+		board.Observe(decode(t, msg))
+	}
+
+	timeline := board.Timeline()
+	if len(timeline.History) != 3 {
+		t.Fatalf("history = %+v", timeline.History)
+	}
+	if c := timeline.Current; c.Version != 4 || c.LeftScore != 1 || c.RightScore != 0 || c.Stage != 2 || c.LeftName != "主队" {
+		t.Fatalf("current = %+v", c)
+	}
+	if h := timeline.History[1]; h.LeftScore != 1 || h.Version != 3 {
+		t.Fatalf("goal change = %+v", h)
+	}
+}
@@ -0,0 +1,13 @@
+package js
+
+import _ "embed"
+
+// SignScript 为 sign.js 原文，打包进二进制后不再依赖工作目录下的脚本文件
+//
+//go:embed sign.js
+var SignScript string
+
+// EnvScript 在 SignScript 之前执行，提供 require("jsdom")、定时器等 node 环境
+//
+//go:embed env.js
+var EnvScript string
@@ -0,0 +1,47 @@
+// sign.js 原本通过 node 运行并依赖 jsdom，这里为内嵌的 JS 引擎提供它用到的最小 node / jsdom 环境
+var global = this;
+var module = { exports: {} };
+var exports = module.exports;
+
+function setTimeout() { return 0; }
+function setInterval() { return 0; }
+function clearTimeout() {}
+function clearInterval() {}
+
+function FakeJSDOM() {
+    var win = {};
+    win.window = win;
+    win.self = win;
+    win.document = {
+        referrer: '',
+        cookie: '',
+        URL: 'about:blank',
+        documentElement: {},
+        createElement: function () { return {}; },
+        addEventListener: function () {}
+    };
+    win.navigator = {
+        userAgent: 'Mozilla/5.0 (linux) AppleWebKit/537.36 (KHTML, like Gecko) jsdom/20.0.3',
+        platform: '',
+        language: 'en-US',
+        languages: ['en-US', 'en'],
+        cookieEnabled: true
+    };
+    win.location = {
+        href: 'about:blank',
+        protocol: 'about:',
+        host: '',
+        hostname: '',
+        pathname: 'blank',
+        search: '',
+        hash: ''
+    };
+    this.window = win;
+}
+
+function require(name) {
+    if (name !== 'jsdom') {
+        throw new Error("Cannot find module '" + name + "'");
+    }
+    return { JSDOM: FakeJSDOM };
+}
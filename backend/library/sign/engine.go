@@ -0,0 +1,99 @@
+package sign
+
+import (
+	"context"
+	"crypto/sha256"
+	"douyinLiveCollectors/backend/library/js"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+const (
+	FailedToCompileScriptError = "FailedToCompileScriptError: %v"
+	FailedToRunScriptError     = "FailedToRunScriptError: %v"
+)
+
+var (
+	compileOnce   sync.Once
+	envProgram    *goja.Program
+	signProgram   *goja.Program
+	compileErr    error
+	InvalidResult = errors.New("signer returned no X-Bogus")
+
+	// ScriptVersion 是内嵌 sign.js 的 sha256 前缀，用于在归档等场景标识签名脚本的版本
+	ScriptVersion = scriptVersion()
+
+	// 测试中可替换为固定的时间与随机数，使结果可复现
+	timeSource goja.Now        = time.Now
+	randSource goja.RandSource = rand.Float64
+)
+
+func scriptVersion() string {
+	sum := sha256.Sum256([]byte(js.SignScript))
+	return hex.EncodeToString(sum[:6])
+}
+
+func compile() {
+	envProgram, compileErr = goja.Compile("env.js", js.EnvScript, false)
+	if compileErr != nil {
+		return
+	}
+	signProgram, compileErr = goja.Compile("sign.js", js.SignScript, false)
+}
+
+// EmbeddedSigner 在内嵌的 JS 引擎中执行 sign.js 计算 X-Bogus。
+// 每次调用使用全新的运行时，与每次启动一个 node 进程的行为保持一致
+type EmbeddedSigner struct{}
+
+func (EmbeddedSigner) Name() string {
+	return BackendEmbedded
+}
+
+func (EmbeddedSigner) Sign(ctx context.Context, stub string) (string, error) {
+	compileOnce.Do(compile)
+	if compileErr != nil {
+		return "", fmt.Errorf(FailedToCompileScriptError, compileErr)
+	}
+
+	vm := goja.New()
+	vm.SetTimeSource(timeSource)
+	vm.SetRandSource(randSource)
+	stop := context.AfterFunc(ctx, func() {
+		vm.Interrupt(ctx.Err())
+	})
+	defer stop()
+
+	if _, err := vm.RunProgram(envProgram); err != nil {
+		return "", fmt.Errorf(FailedToRunScriptError, err)
+	}
+	if _, err := vm.RunProgram(signProgram); err != nil {
+		return "", fmt.Errorf(FailedToRunScriptError, err)
+	}
+	getSign, ok := goja.AssertFunction(vm.Get("getSign"))
+	if !ok {
+		return "", fmt.Errorf(FailedToRunScriptError, "getSign is not a function")
+	}
+
+	param := vm.NewObject()
+	if err := param.Set(stubKey, stub); err != nil {
+		return "", fmt.Errorf(FailedToRunScriptError, err)
+	}
+	result, err := getSign(goja.Undefined(), param)
+	if err != nil {
+		return "", fmt.Errorf(FailedToRunScriptError, err)
+	}
+	if goja.IsUndefined(result) || goja.IsNull(result) {
+		return "", InvalidResult
+	}
+	bogus := result.ToObject(vm).Get(resultKey)
+	if bogus == nil || goja.IsUndefined(bogus) {
+		return "", InvalidResult
+	}
+	return bogus.String(), nil
+}
@@ -1,7 +1,7 @@
 package sign
 
 import (
-	"bytes"
+	"context"
 	"crypto/md5"
 	"douyinLiveCollectors/backend/common/enums"
 	"encoding/hex"
@@ -9,8 +9,6 @@ import (
 	"fmt"
 	"math/rand"
 	"net/url"
-	"os"
-	"os/exec"
 	"strings"
 	"time"
 )
@@ -23,6 +21,9 @@ import (
 const (
 	FailedToParseWssUrlError = "FailedToParseWssUrlError: %v"
 	FailedToCallSignError    = "FailedToCallSignError: %v"
+
+	stubKey   = "X-MS-STUB"
+	resultKey = "X-Bogus"
 )
 
 func GenerateMsToken() string {
@@ -37,12 +38,14 @@ func GenerateMsToken() string {
 	return randomStr.String()
 }
 
-func GenerateSignature(wss string) (string, error) {
-	params := []string{"live_id", "aid", "version_code", "webcast_sdk_version",
-		"room_id", "sub_room_id", "sub_channel_id", "did_rule",
-		"user_unique_id", "device_platform", "device_type", "ac",
-		"identity"}
+// signParams 是参与 X-MS-STUB 计算的 query 参数，顺序固定
+var signParams = []string{"live_id", "aid", "version_code", "webcast_sdk_version",
+	"room_id", "sub_room_id", "sub_channel_id", "did_rule",
+	"user_unique_id", "device_platform", "device_type", "ac",
+	"identity"}
 
+// StubFromUrl 从 wss 地址中提取签名参数并计算 X-MS-STUB (md5)
+func StubFromUrl(wss string) (string, error) {
 	u, err := url.Parse(wss)
 	if err != nil {
 		return "", fmt.Errorf(FailedToParseWssUrlError, err)
@@ -50,7 +53,7 @@ func GenerateSignature(wss string) (string, error) {
 	queryParams := u.Query()
 
 	var tplParams []string
-	for _, param := range params {
+	for _, param := range signParams {
 		value := queryParams.Get(param)
 		tplParams = append(tplParams, fmt.Sprintf("%s=%s", param, value))
 	}
@@ -58,51 +61,25 @@ func GenerateSignature(wss string) (string, error) {
 	paramStr := strings.Join(tplParams, ",")
 	hash := md5.New()
 	hash.Write([]byte(paramStr))
-	md5Param := hex.EncodeToString(hash.Sum(nil))
-
-	scriptFile := "./backend/library/js/sign.js"
-
-	param := map[string]string{"X-MS-STUB": md5Param}
-	jsonParams, _ := json.Marshal(param)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
 
-	cmd := exec.Command("node", scriptFile, string(jsonParams))
-	cmd.Env = append(os.Environ(), "LANG=en_US.UTF-8")
-	var out bytes.Buffer
-	cmd.Stdout = &out
-	err = cmd.Run()
+// GenerateSignature 使用给定的 Signer 为 wss 地址计算 signature
+func GenerateSignature(ctx context.Context, signer Signer, wss string) (string, error) {
+	stub, err := StubFromUrl(wss)
 	if err != nil {
-		return "", fmt.Errorf(FailedToCallSignError, err)
+		return "", err
 	}
-
-	var result map[string]string
-	_ = json.Unmarshal(out.Bytes(), &result)
-	return result["X-Bogus"], nil
+	return signer.Sign(ctx, stub)
 }
 
 func GenerateSignParams(wss string) (string, error) {
-	params := []string{"live_id", "aid", "version_code", "webcast_sdk_version",
-		"room_id", "sub_room_id", "sub_channel_id", "did_rule",
-		"user_unique_id", "device_platform", "device_type", "ac",
-		"identity"}
-
-	u, err := url.Parse(wss)
+	stub, err := StubFromUrl(wss)
 	if err != nil {
-		return "", fmt.Errorf(FailedToParseWssUrlError, err)
+		return "", err
 	}
-	queryParams := u.Query()
-
-	var tplParams []string
-	for _, param := range params {
-		value := queryParams.Get(param)
-		tplParams = append(tplParams, fmt.Sprintf("%s=%s", param, value))
-	}
-
-	paramStr := strings.Join(tplParams, ",")
-	hash := md5.New()
-	hash.Write([]byte(paramStr))
-	md5Param := hex.EncodeToString(hash.Sum(nil))
 
-	param := map[string]string{"X-MS-STUB": md5Param}
+	param := map[string]string{stubKey: stub}
 	jsonParams, _ := json.Marshal(param)
 	return string(jsonParams), nil
 }
@@ -0,0 +1,59 @@
+package sign
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// 期望值由 node 执行同一份 sign.js 得到：Date 固定为 1700000000000，
+// Math.random 替换为下面的 Park-Miller 序列（seed = 1），jsdom 使用 env.js 中的 FakeJSDOM
+var golden = map[string]string{
+	"0123456789abcdef0123456789abcdef": "f8p15z9f37jgtxw1",
+	"5d41402abc4b2a76b9719d911017c592": "f8p15z9f37jdVxwh",
+	"d41d8cd98f00b204e9800998ecf8427e": "f8p15z9f37rXbbfq",
+}
+
+func fixSources(t *testing.T) {
+	t.Helper()
+	oldTime, oldRand := timeSource, randSource
+	t.Cleanup(func() {
+		timeSource, randSource = oldTime, oldRand
+	})
+	timeSource = func() time.Time {
+		return time.UnixMilli(1700000000000)
+	}
+}
+
+func resetRand() {
+	seed := int64(1)
+	randSource = func() float64 {
+		seed = seed * 16807 % 2147483647
+		return float64(seed) / 2147483647
+	}
+}
+
+func TestSignMatchesNode(t *testing.T) {
+	fixSources(t)
+	for stub, want := range golden {
+		resetRand()
+		got, err := EmbeddedSigner{}.Sign(context.Background(), stub)
+		if err != nil {
+			t.Fatalf("Sign(%s): %v", stub, err)
+		}
+		if got != want {
+			t.Errorf("Sign(%s) = %s, want %s", stub, got, want)
+		}
+	}
+}
+
+func TestGenerateSignatureEmbedded(t *testing.T) {
+	wss := "wss://example.com/webcast/im/push/v2/?live_id=1&aid=6383&room_id=7400000000000000001&identity=audience"
+	signature, err := GenerateSignature(context.Background(), EmbeddedSigner{}, wss)
+	if err != nil {
+		t.Fatalf("GenerateSignature: %v", err)
+	}
+	if signature == "" {
+		t.Fatal("empty signature")
+	}
+}
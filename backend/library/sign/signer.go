@@ -0,0 +1,285 @@
+package sign
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	BackendEmbedded = "embedded"
+	BackendNode     = "node"
+	BackendRemote   = "remote"
+
+	UnknownBackendError     = "UnknownBackendError: %s"
+	FailedToCallRemoteError = "FailedToCallRemoteError: %v"
+	SignerFailedError       = "SignerFailedError: %s: %w"
+
+	defaultCacheSize = 256
+)
+
+var (
+	NoSigner = errors.New("no signer configured")
+)
+
+// Signer 根据 X-MS-STUB 计算 X-Bogus，不同的后端可以互相替换
+type Signer interface {
+	Name() string
+	Sign(ctx context.Context, stub string) (string, error)
+}
+
+// Options 描述如何组装 Signer：Backends 按顺序组成回退链，前一个失败时尝试下一个
+type Options struct {
+	Backends   []string
+	NodePath   string
+	ScriptPath string
+	RemoteUrl  string
+	Timeout    time.Duration
+	CacheTTL   time.Duration
+}
+
+// New 按 Options 组装 Signer：每个后端带超时，整体按 stub 缓存结果
+func New(opts Options) (Signer, error) {
+	var signers []Signer
+	for _, backend := range opts.Backends {
+		var signer Signer
+		switch strings.TrimSpace(backend) {
+		case BackendEmbedded:
+			signer = EmbeddedSigner{}
+		case BackendNode:
+			signer = &NodeSigner{Node: opts.NodePath, Script: opts.ScriptPath}
+		case BackendRemote:
+			signer = &RemoteSigner{Url: opts.RemoteUrl}
+		default:
+			return nil, fmt.Errorf(UnknownBackendError, backend)
+		}
+		if opts.Timeout > 0 {
+			signer = WithTimeout(signer, opts.Timeout)
+		}
+		signers = append(signers, signer)
+	}
+	if len(signers) == 0 {
+		return nil, NoSigner
+	}
+
+	signer := signers[0]
+	if len(signers) > 1 {
+		signer = Chain(signers...)
+	}
+	if opts.CacheTTL > 0 {
+		signer = WithCache(signer, opts.CacheTTL, defaultCacheSize)
+	}
+	return signer, nil
+}
+
+// NodeSigner 通过 node 执行外部的 sign.js，每次调用启动一个进程
+type NodeSigner struct {
+	Node   string
+	Script string
+}
+
+func (s *NodeSigner) Name() string {
+	return BackendNode
+}
+
+func (s *NodeSigner) Sign(ctx context.Context, stub string) (string, error) {
+	node := s.Node
+	if node == "" {
+		node = "node"
+	}
+	jsonParams, _ := json.Marshal(map[string]string{stubKey: stub})
+
+	cmd := exec.CommandContext(ctx, node, s.Script, string(jsonParams))
+	cmd.Env = append(os.Environ(), "LANG=en_US.UTF-8")
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf(FailedToCallSignError, err)
+	}
+	return decodeResult(out.Bytes())
+}
+
+// RemoteSigner 调用 HTTP 签名服务：POST {"X-MS-STUB": stub}，返回 {"X-Bogus": "..."}
+type RemoteSigner struct {
+	Url    string
+	Client *http.Client
+}
+
+func (s *RemoteSigner) Name() string {
+	return BackendRemote
+}
+
+func (s *RemoteSigner) Sign(ctx context.Context, stub string) (string, error) {
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	body, _ := json.Marshal(map[string]string{stubKey: stub})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.Url, bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf(FailedToCallRemoteError, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf(FailedToCallRemoteError, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf(FailedToCallRemoteError, resp.Status)
+	}
+	var out bytes.Buffer
+	if _, err := out.ReadFrom(resp.Body); err != nil {
+		return "", fmt.Errorf(FailedToCallRemoteError, err)
+	}
+	return decodeResult(out.Bytes())
+}
+
+// Handler 以 RemoteSigner 使用的协议对外提供签名服务，可用于本地搭建或模拟签名服务
+func Handler(signer Signer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var param map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&param); err != nil || param[stubKey] == "" {
+			http.Error(w, "missing "+stubKey, http.StatusBadRequest)
+			return
+		}
+		bogus, err := signer.Sign(r.Context(), param[stubKey])
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{resultKey: bogus})
+	})
+}
+
+func decodeResult(data []byte) (string, error) {
+	var result map[string]string
+	if err := json.Unmarshal(data, &result); err != nil {
+		return "", fmt.Errorf(FailedToCallSignError, err)
+	}
+	if result[resultKey] == "" {
+		return "", InvalidResult
+	}
+	return result[resultKey], nil
+}
+
+type timeoutSigner struct {
+	Signer
+	timeout time.Duration
+}
+
+// WithTimeout 限制单次签名的耗时
+func WithTimeout(signer Signer, timeout time.Duration) Signer {
+	return &timeoutSigner{Signer: signer, timeout: timeout}
+}
+
+func (s *timeoutSigner) Sign(ctx context.Context, stub string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.Signer.Sign(ctx, stub)
+}
+
+type chainSigner []Signer
+
+// Chain 依次尝试各个 Signer，返回第一个成功的结果；全部失败时返回合并后的错误
+func Chain(signers ...Signer) Signer {
+	return chainSigner(signers)
+}
+
+func (c chainSigner) Name() string {
+	names := make([]string, len(c))
+	for i, signer := range c {
+		names[i] = signer.Name()
+	}
+	return strings.Join(names, ",")
+}
+
+func (c chainSigner) Sign(ctx context.Context, stub string) (string, error) {
+	var errs []error
+	for _, signer := range c {
+		bogus, err := signer.Sign(ctx, stub)
+		if err == nil {
+			return bogus, nil
+		}
+		errs = append(errs, fmt.Errorf(SignerFailedError, signer.Name(), err))
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return "", errors.Join(errs...)
+}
+
+type cacheEntry struct {
+	bogus   string
+	expires time.Time
+}
+
+type cachedSigner struct {
+	Signer
+	ttl     time.Duration
+	size    int
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// WithCache 按 stub 缓存签名结果 ttl 时长，最多保留 size 条
+func WithCache(signer Signer, ttl time.Duration, size int) Signer {
+	return &cachedSigner{
+		Signer:  signer,
+		ttl:     ttl,
+		size:    size,
+		entries: make(map[string]cacheEntry),
+	}
+}
+
+func (s *cachedSigner) Sign(ctx context.Context, stub string) (string, error) {
+	now := time.Now()
+	s.mu.Lock()
+	entry, ok := s.entries[stub]
+	s.mu.Unlock()
+	if ok && now.Before(entry.expires) {
+		return entry.bogus, nil
+	}
+
+	bogus, err := s.Signer.Sign(ctx, stub)
+	if err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.entries) >= s.size {
+		s.evict(now)
+	}
+	s.entries[stub] = cacheEntry{bogus: bogus, expires: now.Add(s.ttl)}
+	return bogus, nil
+}
+
+// evict 先清理过期条目，仍然已满时任意淘汰一条
+func (s *cachedSigner) evict(now time.Time) {
+	for key, entry := range s.entries {
+		if !now.Before(entry.expires) {
+			delete(s.entries, key)
+		}
+	}
+	for key := range s.entries {
+		if len(s.entries) < s.size {
+			return
+		}
+		delete(s.entries, key)
+	}
+}
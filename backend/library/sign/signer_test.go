@@ -0,0 +1,64 @@
+package sign
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeSigner struct {
+	name  string
+	bogus string
+	err   error
+	calls int
+}
+
+func (s *fakeSigner) Name() string {
+	return s.name
+}
+
+func (s *fakeSigner) Sign(ctx context.Context, stub string) (string, error) {
+	s.calls++
+	return s.bogus, s.err
+}
+
+func TestChainFallbackAndCache(t *testing.T) {
+	broken := &fakeSigner{name: "broken", err: errors.New("boom")}
+	working := &fakeSigner{name: "working", bogus: "bogus"}
+	signer := WithCache(Chain(broken, working), time.Minute, 2)
+
+	for i := 0; i < 3; i++ {
+		got, err := signer.Sign(context.Background(), "stub")
+		if err != nil || got != "bogus" {
+			t.Fatalf("Sign = %q, %v", got, err)
+		}
+	}
+	if broken.calls != 1 || working.calls != 1 {
+		t.Fatalf("calls = %d, %d, want cached after first call", broken.calls, working.calls)
+	}
+
+	_, err := Chain(broken).Sign(context.Background(), "other")
+	if !errors.Is(err, broken.err) {
+		t.Fatalf("chain error = %v, want %v", err, broken.err)
+	}
+}
+
+func TestRemoteSigner(t *testing.T) {
+	server := httptest.NewServer(Handler(&fakeSigner{name: "fake", bogus: "remote-bogus"}))
+	defer server.Close()
+
+	signer, err := New(Options{Backends: []string{BackendRemote}, RemoteUrl: server.URL, Timeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := GenerateSignature(context.Background(), signer, "wss://example.com/?room_id=1")
+	if err != nil || got != "remote-bogus" {
+		t.Fatalf("GenerateSignature = %q, %v", got, err)
+	}
+
+	if _, err := New(Options{Backends: []string{"python"}}); err == nil {
+		t.Fatal("expected unknown backend error")
+	}
+}
@@ -0,0 +1,105 @@
+// replay 在命令行中回放 recordDir 下录制的帧归档，逐行输出解析后的消息。
+//
+//	go run ./cmd/replay [-speed 0] [-from 10m | -from 2024-07-16T12:00:00+08:00] [-json | -format markdown] file.dyla
+//	go run ./cmd/replay -catalog ./unknown -drift file.dyla
+//	go run ./cmd/replay -list ./records
+package main
+
+import (
+	"context"
+	"douyinLiveCollectors/backend/common/handler"
+	"douyinLiveCollectors/backend/common/protodump"
+	"douyinLiveCollectors/backend/common/replay"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+)
+
+var formatters = map[string]handler.Formatter{
+	"text":     handler.TextFormatter,
+	"html":     handler.HTMLFormatter,
+	"markdown": handler.MarkdownFormatter,
+}
+
+func main() {
+	speed := flag.Float64("speed", 0, "回放速度，1 为实时，0 为尽快回放")
+	from := flag.String("from", "", "起始位置：相对会话开始的时长（如 10m）或 RFC3339 时间")
+	asJson := flag.Bool("json", false, "以 JSON 输出结构化事件")
+	format := flag.String("format", "text", "文本格式：text、html 或 markdown，html 与 markdown 保留富文本样式")
+	list := flag.Bool("list", false, "列出目录中的归档")
+	drift := flag.Bool("drift", false, "回放结束后输出协议变化统计")
+	catalog := flag.String("catalog", "", "将未知方法的消息按 wire 格式解析，按方法汇总到该目录")
+	flag.Parse()
+	if flag.NArg() != 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *list {
+		recordings, err := replay.List(flag.Arg(0))
+		if err != nil {
+			fatal(err)
+		}
+		for _, r := range recordings {
+			fmt.Printf("%s\tlive %d\troom %s\t%s\t%d bytes\n", r.Path, r.Header.LiveId, r.Header.RoomId,
+				r.Header.StartedAt.Format(time.RFC3339), r.Size)
+		}
+		return
+	}
+
+	formatter, ok := formatters[*format]
+	if !ok {
+		fatal(fmt.Errorf("unknown format %q", *format))
+	}
+	opts := replay.Options{Speed: *speed}
+	if *catalog != "" {
+		opts.Handler.Catalog = protodump.OpenCatalog(*catalog)
+	}
+	if *from != "" {
+		if offset, err := time.ParseDuration(*from); err == nil {
+			opts.Offset = offset
+		} else if opts.From, err = time.Parse(time.RFC3339, *from); err != nil {
+			fatal(err)
+		}
+	}
+	player, err := replay.New(flag.Arg(0), opts)
+	if err != nil {
+		fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- player.Run(ctx)
+	}()
+	encoder := json.NewEncoder(os.Stdout)
+	for event := range player.Out {
+		if *asJson {
+			encoder.Encode(struct {
+				Type  string
+				Event handler.Event
+			}{fmt.Sprintf("%T", event), event})
+			continue
+		}
+		if text := formatter.Format(event); text != "" {
+			fmt.Println(text)
+		}
+	}
+	if err := <-done; err != nil && err != context.Canceled {
+		fatal(err)
+	}
+	if *drift {
+		report, _ := json.MarshalIndent(player.DriftReport(), "", "  ")
+		fmt.Fprintln(os.Stderr, string(report))
+	}
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
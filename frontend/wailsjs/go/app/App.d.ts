@@ -1,6 +1,41 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {config} from '../models';
+import {handler} from '../models';
+import {replay} from '../models';
+import {room} from '../models';
+import {tracker} from '../models';
+
+export function DriftReport(arg1:number):Promise<handler.DriftReport>;
+
+export function FanTicketSeries(arg1:number):Promise<Array<tracker.FanTicketPoint>>;
+
+export function GetConfig():Promise<config.Config>;
+
+export function ListRecordings():Promise<Array<replay.Recording>>;
+
+export function ListRooms():Promise<Array<room.Status>>;
+
+export function MatchTimeline(arg1:number):Promise<tracker.MatchTimeline>;
+
+export function NoticeScenes(arg1:number):Promise<Array<tracker.SceneStat>>;
+
+export function ProductTimeline(arg1:number):Promise<Array<tracker.ProductWindow>>;
+
+export function RemoveRoom(arg1:number):Promise<string>;
+
+export function RoomStatus(arg1:number):Promise<room.Status>;
+
+export function SaveConfig(arg1:config.Config):Promise<string>;
 
 export function Shutdown():Promise<void>;
 
 export function Start(arg1:number):Promise<string>;
+
+export function StartReplay(arg1:string,arg2:number,arg3:number):Promise<string>;
+
+export function StartRoom(arg1:number):Promise<string>;
+
+export function StopReplay(arg1:string):Promise<string>;
+
+export function StopRoom(arg1:number):Promise<string>;
@@ -2,6 +2,50 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function DriftReport(arg1) {
+  return window['go']['app']['App']['DriftReport'](arg1);
+}
+
+export function FanTicketSeries(arg1) {
+  return window['go']['app']['App']['FanTicketSeries'](arg1);
+}
+
+export function GetConfig() {
+  return window['go']['app']['App']['GetConfig']();
+}
+
+export function ListRecordings() {
+  return window['go']['app']['App']['ListRecordings']();
+}
+
+export function ListRooms() {
+  return window['go']['app']['App']['ListRooms']();
+}
+
+export function MatchTimeline(arg1) {
+  return window['go']['app']['App']['MatchTimeline'](arg1);
+}
+
+export function NoticeScenes(arg1) {
+  return window['go']['app']['App']['NoticeScenes'](arg1);
+}
+
+export function ProductTimeline(arg1) {
+  return window['go']['app']['App']['ProductTimeline'](arg1);
+}
+
+export function RemoveRoom(arg1) {
+  return window['go']['app']['App']['RemoveRoom'](arg1);
+}
+
+export function RoomStatus(arg1) {
+  return window['go']['app']['App']['RoomStatus'](arg1);
+}
+
+export function SaveConfig(arg1) {
+  return window['go']['app']['App']['SaveConfig'](arg1);
+}
+
 export function Shutdown() {
   return window['go']['app']['App']['Shutdown']();
 }
@@ -9,3 +53,19 @@ export function Shutdown() {
 export function Start(arg1) {
   return window['go']['app']['App']['Start'](arg1);
 }
+
+export function StartReplay(arg1, arg2, arg3) {
+  return window['go']['app']['App']['StartReplay'](arg1, arg2, arg3);
+}
+
+export function StartRoom(arg1) {
+  return window['go']['app']['App']['StartRoom'](arg1);
+}
+
+export function StopReplay(arg1) {
+  return window['go']['app']['App']['StopReplay'](arg1);
+}
+
+export function StopRoom(arg1) {
+  return window['go']['app']['App']['StopRoom'](arg1);
+}
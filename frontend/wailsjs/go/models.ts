@@ -0,0 +1,493 @@
+export namespace archive {
+	
+	export class Header {
+	    Version: number;
+	    LiveId: number;
+	    RoomId: string;
+	    Signer: string;
+	    SignerVersion: string;
+	    CollectorVersion: string;
+	    // Go type: time
+	    StartedAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Header(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Version = source["Version"];
+	        this.LiveId = source["LiveId"];
+	        this.RoomId = source["RoomId"];
+	        this.Signer = source["Signer"];
+	        this.SignerVersion = source["SignerVersion"];
+	        this.CollectorVersion = source["CollectorVersion"];
+	        this.StartedAt = this.convertValues(source["StartedAt"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace config {
+	
+	export class Config {
+	    webHost: string;
+	    wssHost: string;
+	    userAgent: string;
+	    deviceId: string;
+	    versionCode: string;
+	    sdkVersion: string;
+	    requestTimeout: number;
+	    dialTimeout: number;
+	    heartbeatInterval: number;
+	    dispatchWorkers: number;
+	    outputBuffer: number;
+	    outputPolicy: string;
+	    dedupSize: number;
+	    dedupTTL: number;
+	    recordDir: string;
+	    catalogDir: string;
+	    signers: string[];
+	    signerPath: string;
+	    signerUrl: string;
+	    signTimeout: number;
+	    signCacheTTL: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.webHost = source["webHost"];
+	        this.wssHost = source["wssHost"];
+	        this.userAgent = source["userAgent"];
+	        this.deviceId = source["deviceId"];
+	        this.versionCode = source["versionCode"];
+	        this.sdkVersion = source["sdkVersion"];
+	        this.requestTimeout = source["requestTimeout"];
+	        this.dialTimeout = source["dialTimeout"];
+	        this.heartbeatInterval = source["heartbeatInterval"];
+	        this.dispatchWorkers = source["dispatchWorkers"];
+	        this.outputBuffer = source["outputBuffer"];
+	        this.outputPolicy = source["outputPolicy"];
+	        this.dedupSize = source["dedupSize"];
+	        this.dedupTTL = source["dedupTTL"];
+	        this.recordDir = source["recordDir"];
+	        this.catalogDir = source["catalogDir"];
+	        this.signers = source["signers"];
+	        this.signerPath = source["signerPath"];
+	        this.signerUrl = source["signerUrl"];
+	        this.signTimeout = source["signTimeout"];
+	        this.signCacheTTL = source["signCacheTTL"];
+	    }
+	}
+
+}
+
+export namespace handler {
+	
+	export class UnknownField {
+	    Path: string;
+	    WireType: string;
+	    Count: number;
+	    // Go type: time
+	    FirstSeen: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new UnknownField(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.WireType = source["WireType"];
+	        this.Count = source["Count"];
+	        this.FirstSeen = this.convertValues(source["FirstSeen"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MethodDrift {
+	    Method: string;
+	    Type: string;
+	    Decoded: number;
+	    Checked: number;
+	    Errors: number;
+	    ErrorRate: number;
+	    UnknownFields: UnknownField[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MethodDrift(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Method = source["Method"];
+	        this.Type = source["Type"];
+	        this.Decoded = source["Decoded"];
+	        this.Checked = source["Checked"];
+	        this.Errors = source["Errors"];
+	        this.ErrorRate = source["ErrorRate"];
+	        this.UnknownFields = this.convertValues(source["UnknownFields"], UnknownField);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DriftReport {
+	    Methods: MethodDrift[];
+	    UnknownMethods: {[key: string]: number};
+	
+	    static createFrom(source: any = {}) {
+	        return new DriftReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Methods = this.convertValues(source["Methods"], MethodDrift);
+	        this.UnknownMethods = source["UnknownMethods"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace replay {
+	
+	export class Recording {
+	    Path: string;
+	    Size: number;
+	    Header: archive.Header;
+	
+	    static createFrom(source: any = {}) {
+	        return new Recording(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Path = source["Path"];
+	        this.Size = source["Size"];
+	        this.Header = this.convertValues(source["Header"], archive.Header);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace room {
+	
+	export class Status {
+	    LiveId: number;
+	    RoomId: string;
+	    State: string;
+	    StartedAt: string;
+	    UpdatedAt: string;
+	    Messages: number;
+	    Dropped: number;
+	    Reconnects: number;
+	    LastError: string;
+	    RecordPath: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.LiveId = source["LiveId"];
+	        this.RoomId = source["RoomId"];
+	        this.State = source["State"];
+	        this.StartedAt = source["StartedAt"];
+	        this.UpdatedAt = source["UpdatedAt"];
+	        this.Messages = source["Messages"];
+	        this.Dropped = source["Dropped"];
+	        this.Reconnects = source["Reconnects"];
+	        this.LastError = source["LastError"];
+	        this.RecordPath = source["RecordPath"];
+	    }
+	}
+
+}
+
+export namespace tracker {
+	
+	export class FanTicketPoint {
+	    // Go type: time
+	    Time: any;
+	    Count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new FanTicketPoint(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Time = this.convertValues(source["Time"], null);
+	        this.Count = source["Count"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MatchState {
+	    // Go type: time
+	    Time: any;
+	    LeftName: string;
+	    RightName: string;
+	    LeftGoal: string;
+	    RightGoal: string;
+	    LeftScore: number;
+	    RightScore: number;
+	    Stage: number;
+	    FinalStage: number;
+	    MatchStatus: number;
+	    Version: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MatchState(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Time = this.convertValues(source["Time"], null);
+	        this.LeftName = source["LeftName"];
+	        this.RightName = source["RightName"];
+	        this.LeftGoal = source["LeftGoal"];
+	        this.RightGoal = source["RightGoal"];
+	        this.LeftScore = source["LeftScore"];
+	        this.RightScore = source["RightScore"];
+	        this.Stage = source["Stage"];
+	        this.FinalStage = source["FinalStage"];
+	        this.MatchStatus = source["MatchStatus"];
+	        this.Version = source["Version"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class MatchTimeline {
+	    Current: MatchState;
+	    History: MatchState[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MatchTimeline(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Current = this.convertValues(source["Current"], MatchState);
+	        this.History = this.convertValues(source["History"], MatchState);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ProductWindow {
+	    PromotionId: number;
+	    // Go type: time
+	    Start: any;
+	    // Go type: time
+	    End: any;
+	    Chats: number;
+	    Gifts: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProductWindow(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.PromotionId = source["PromotionId"];
+	        this.Start = this.convertValues(source["Start"], null);
+	        this.End = this.convertValues(source["End"], null);
+	        this.Chats = source["Chats"];
+	        this.Gifts = source["Gifts"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SceneStat {
+	    Scene: string;
+	    Count: number;
+	    LastText: string;
+	    // Go type: time
+	    LastSeen: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new SceneStat(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.Scene = source["Scene"];
+	        this.Count = source["Count"];
+	        this.LastText = source["LastText"];
+	        this.LastSeen = this.convertValues(source["LastSeen"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+